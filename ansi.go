@@ -0,0 +1,63 @@
+package logparser
+
+import (
+	"strings"
+)
+
+const (
+	esc = '\x1b'
+	bel = '\x07'
+)
+
+// StripANSI removes ANSI/VT100 escape sequences (colors, cursor movements, terminal titles) from s.
+func StripANSI(s string) string {
+	i := strings.IndexByte(s, esc)
+	if i < 0 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i >= 0 {
+		b.WriteString(s[:i])
+		s = s[skipEscapeSequence(s, i):]
+		i = strings.IndexByte(s, esc)
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// skipEscapeSequence returns the position right after the escape sequence starting at i
+func skipEscapeSequence(s string, i int) int {
+	i++
+	if i >= len(s) {
+		return i
+	}
+	switch s[i] {
+	case '[': // CSI: ESC [ parameters intermediates final
+		for i++; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+			if s[i] < 0x20 || s[i] > 0x7e { // malformed sequence
+				return i
+			}
+		}
+		return i
+	case ']', 'P', '_', '^': // OSC, DCS, APC, PM: terminated by BEL or ESC \
+		for i++; i < len(s); i++ {
+			if s[i] == bel {
+				return i + 1
+			}
+			if s[i] == esc && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return i
+	case '(', ')', '*', '+', '#': // charset selection: ESC ( B
+		if i+1 < len(s) {
+			return i + 2
+		}
+		return i + 1
+	}
+	return i + 1
+}
@@ -0,0 +1,27 @@
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripANSI(t *testing.T) {
+	assert.Equal(t, "no escapes", StripANSI("no escapes"))
+	assert.Equal(t, "ERROR failed to connect", StripANSI("\x1b[31mERROR\x1b[0m failed to connect"))
+	assert.Equal(t, "2026-10-14 10:00:00 INFO started", StripANSI("\x1b[2m2026-10-14 10:00:00\x1b[0m \x1b[1;32mINFO\x1b[0m started"))
+	assert.Equal(t, "WARN 256 colors", StripANSI("\x1b[38;5;208mWARN\x1b[39m 256 colors"))
+	assert.Equal(t, "title", StripANSI("\x1b]0;my terminal\x07title"))
+	assert.Equal(t, "link", StripANSI("\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\"))
+	assert.Equal(t, "cleared", StripANSI("\x1b[2K\x1b[1Gcleared"))
+	assert.Equal(t, "charset", StripANSI("\x1b(Bcharset"))
+	assert.Equal(t, "trailing", StripANSI("trailing\x1b"))
+	assert.Equal(t, "trailing", StripANSI("trailing\x1b[31"))
+}
+
+func TestANSIColoredLevelAndPattern(t *testing.T) {
+	colored := "\x1b[31mERROR\x1b[0m [main] failed to connect to \x1b[1mdb\x1b[0m"
+	plain := "ERROR [main] failed to connect to db"
+	assert.Equal(t, LevelError, GuessLevel(StripANSI(colored)))
+	assert.Equal(t, NewPattern(plain).String(), NewPattern(StripANSI(colored)).String())
+}
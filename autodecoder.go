@@ -0,0 +1,173 @@
+package logparser
+
+import (
+	"encoding/json"
+	"regexp"
+	"sync"
+)
+
+const (
+	// autoDecoderDetectLines is the number of lines the format is detected by
+	autoDecoderDetectLines = 5
+	// autoDecoderMaxFailures is the number of consecutive decoding failures after which the format is detected again
+	autoDecoderMaxFailures = 10
+)
+
+type Format int
+
+const (
+	FormatUnknown Format = iota
+	FormatPlain
+	FormatDocker
+	FormatCRI
+	FormatSyslog
+	FormatJournald
+	FormatKmsg
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatPlain:
+		return "plain"
+	case FormatDocker:
+		return "docker"
+	case FormatCRI:
+		return "cri"
+	case FormatSyslog:
+		return "syslog"
+	case FormatJournald:
+		return "journald"
+	case FormatKmsg:
+		return "kmsg"
+	}
+	return "unknown"
+}
+
+type formatDetector struct {
+	format  Format
+	match   func(line string) bool
+	decoder EntryDecoder
+}
+
+var (
+	// 2016-10-06T00:17:09.669794202Z stdout F
+	criLineRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2}) (stdout|stderr) [PF] `)
+
+	// the more specific formats go first, plain text matches any line
+	formatDetectors = []formatDetector{
+		{format: FormatCRI, match: criLineRe.MatchString, decoder: CriDecoder{}},
+		{format: FormatDocker, match: isDockerJsonLine, decoder: DockerJsonDecoder{}},
+		{format: FormatJournald, match: isJournaldJsonLine, decoder: JournaldJsonDecoder{}},
+		{format: FormatKmsg, match: isKmsgLine, decoder: KmsgDecoder{}},
+		{format: FormatSyslog, match: isSyslogLine, decoder: SyslogDecoder{}},
+		{format: FormatPlain, match: func(string) bool { return true }, decoder: plainDecoder{}},
+	}
+)
+
+func isDockerJsonLine(line string) bool {
+	return isJsonLineWithKey(line, "log")
+}
+
+// `journalctl -o json`
+func isJournaldJsonLine(line string) bool {
+	return isJsonLineWithKey(line, "__REALTIME_TIMESTAMP")
+}
+
+func isJsonLineWithKey(line, key string) bool {
+	if len(line) == 0 || line[0] != '{' {
+		return false
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(line), &obj); err != nil {
+		return false
+	}
+	_, ok := obj[key]
+	return ok
+}
+
+func isSyslogLine(line string) bool {
+	_, _, ok := parseSyslogPriority(line)
+	return ok
+}
+
+type plainDecoder struct{}
+
+func (d plainDecoder) DecodeEntry(src string) (LogEntry, error) {
+	return LogEntry{Content: src}, nil
+}
+
+// AutoDecoder detects the format of the input (Docker json-file, CRI, syslog, journald JSON, kernel log or plain text) by the first lines
+// and decodes the following lines with the decoder of the detected format.
+// The format is detected again if the decoder keeps failing (e.g., the container runtime has been changed);
+// plain text never fails, so it's kept once detected.
+type AutoDecoder struct {
+	lock     sync.Mutex
+	detector *formatDetector
+	votes    []int
+	lines    int
+	failures int
+}
+
+func NewAutoDecoder() *AutoDecoder {
+	return &AutoDecoder{votes: make([]int, len(formatDetectors))}
+}
+
+// Format returns the detected format or FormatUnknown if the detection is in progress.
+func (d *AutoDecoder) Format() Format {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.detector == nil {
+		return FormatUnknown
+	}
+	return d.detector.format
+}
+
+func (d *AutoDecoder) Decode(src string) (string, error) {
+	entry, err := d.DecodeEntry(src)
+	return entry.Content, err
+}
+
+func (d *AutoDecoder) DecodeEntry(src string) (LogEntry, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.detector == nil {
+		return d.detect(src).decoder.DecodeEntry(src)
+	}
+	entry, err := d.detector.decoder.DecodeEntry(src)
+	if err != nil {
+		d.failures++
+		if d.failures >= autoDecoderMaxFailures {
+			d.detector = nil
+		}
+		return entry, err
+	}
+	d.failures = 0
+	return entry, nil
+}
+
+// detect votes for the format of the line and settles on the most voted format after autoDecoderDetectLines lines
+func (d *AutoDecoder) detect(line string) *formatDetector {
+	detected := len(formatDetectors) - 1
+	for i := range formatDetectors {
+		if formatDetectors[i].match(line) {
+			detected = i
+			break
+		}
+	}
+	d.votes[detected]++
+	d.lines++
+	if d.lines >= autoDecoderDetectLines {
+		best := 0
+		for i, votes := range d.votes {
+			if votes > d.votes[best] { // on a tie, the more specific format wins
+				best = i
+			}
+		}
+		d.detector = &formatDetectors[best]
+		clear(d.votes)
+		d.lines = 0
+		d.failures = 0
+	}
+	return &formatDetectors[detected]
+}
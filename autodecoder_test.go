@@ -0,0 +1,92 @@
+package logparser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoDecoder(t *testing.T) {
+	d := NewAutoDecoder()
+	assert.Equal(t, FormatUnknown, d.Format())
+
+	cri := "2016-10-06T00:17:09.669794202Z stdout F "
+	for i := 0; i < autoDecoderDetectLines-1; i++ {
+		entry, err := d.DecodeEntry(cri + "hello")
+		require.NoError(t, err)
+		assert.Equal(t, "hello", entry.Content)
+		assert.Equal(t, time.Date(2016, 10, 6, 0, 17, 9, 669794202, time.UTC), entry.Timestamp)
+	}
+	assert.Equal(t, FormatUnknown, d.Format())
+
+	// a line of a different format during the detection is decoded by its own decoder
+	content, err := d.Decode("hello")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", content)
+	assert.Equal(t, FormatCRI, d.Format())
+	assert.Equal(t, "cri", d.Format().String())
+
+	_, err = d.Decode("hello")
+	assert.Error(t, err)
+	content, err = d.Decode(cri + "world")
+	require.NoError(t, err)
+	assert.Equal(t, "world", content)
+
+	// the runtime has been changed
+	docker := `{"log":"hello\n","stream":"stdout","time":"2024-02-16T10:11:12Z"}`
+	for i := 0; i < autoDecoderMaxFailures; i++ {
+		_, err = d.Decode(docker)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, FormatUnknown, d.Format())
+	for i := 0; i < autoDecoderDetectLines; i++ {
+		content, err = d.Decode(docker)
+		require.NoError(t, err)
+		assert.Equal(t, "hello\n", content)
+	}
+	assert.Equal(t, FormatDocker, d.Format())
+
+	d = NewAutoDecoder()
+	for _, line := range []string{`{"level":"info","msg":"started"}`, "plain", "text", docker, "lines"} {
+		content, err = d.Decode(line)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, FormatPlain, d.Format())
+	content, err = d.Decode(docker)
+	require.NoError(t, err)
+	assert.Equal(t, docker, content)
+}
+
+func TestAutoDecoderSyslog(t *testing.T) {
+	d := NewAutoDecoder()
+	for i := 0; i < autoDecoderDetectLines; i++ {
+		entry, err := d.DecodeEntry(`<11>1 2026-01-02T09:00:00Z db-0 postgres 77 - - FATAL:  password authentication failed`)
+		require.NoError(t, err)
+		assert.Equal(t, "FATAL:  password authentication failed", entry.Content)
+		assert.Equal(t, LevelError, entry.Level)
+	}
+	assert.Equal(t, FormatSyslog, d.Format())
+}
+
+func TestAutoDecoderJournald(t *testing.T) {
+	d := NewAutoDecoder()
+	for i := 0; i < autoDecoderDetectLines; i++ {
+		entry, err := d.DecodeEntry(`{"__REALTIME_TIMESTAMP":"1700000000123456","PRIORITY":"3","MESSAGE":"failed"}`)
+		require.NoError(t, err)
+		assert.Equal(t, LevelError, entry.Level)
+	}
+	assert.Equal(t, FormatJournald, d.Format())
+}
+
+func TestAutoDecoderKmsg(t *testing.T) {
+	d := NewAutoDecoder()
+	for i := 0; i < autoDecoderDetectLines; i++ {
+		entry, err := d.DecodeEntry(`<3>[12345.678901] nvme0: I/O timeout`)
+		require.NoError(t, err)
+		assert.Equal(t, "nvme0: I/O timeout", entry.Content)
+		assert.Equal(t, LevelError, entry.Level)
+	}
+	assert.Equal(t, FormatKmsg, d.Format())
+}
@@ -0,0 +1,24 @@
+package logparser
+
+import "time"
+
+// fakeClock is the Clock of the package's tests: the time stands still, and the tickers never fire.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c fakeClock) NewTicker(time.Duration) Ticker {
+	return fakeTicker{}
+}
+
+type fakeTicker struct{}
+
+func (fakeTicker) C() <-chan time.Time {
+	return nil
+}
+
+func (fakeTicker) Stop() {}
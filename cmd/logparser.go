@@ -18,12 +18,24 @@ import (
 func main() {
 	screenWidth := flag.Int("w", 120, "terminal width")
 	maxLinesPerMessage := flag.Int("l", 100, "max lines per message")
+	levelRulesFile := flag.String("level-rules", "", "path to a JSON file with level rules")
 
 	flag.Parse()
 
+	var opts []logparser.ParserOption
+	if *levelRulesFile != "" {
+		rules, err := logparser.LoadLevelRules(*levelRulesFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		opts = append(opts, logparser.WithParserLevelRules(rules))
+	}
+
 	reader := bufio.NewReader(os.Stdin)
 	ch := make(chan logparser.LogEntry)
-	parser := logparser.NewParser(ch, nil, nil, time.Second, 256)
+	decoder := logparser.NewAutoDecoder()
+	parser := logparser.NewParser(ch, decoder, nil, time.Second, 256, opts...)
 	t := time.Now()
 	for {
 		line, err := reader.ReadString('\n')
@@ -33,7 +45,7 @@ func main() {
 			}
 			break
 		}
-		ch <- logparser.LogEntry{Timestamp: time.Now(), Content: strings.TrimSuffix(line, "\n"), Level: logparser.LevelUnknown}
+		ch <- logparser.LogEntry{Content: strings.TrimSuffix(line, "\n"), Level: logparser.LevelUnknown}
 	}
 	d := time.Since(t)
 	defer parser.Stop()
@@ -43,6 +55,7 @@ func main() {
 	order(counters)
 
 	output(counters, *screenWidth, *maxLinesPerMessage, d)
+	fmt.Printf("input format: %s\n\n", decoder.Format())
 }
 
 func order(counters []logparser.LogCounter) {
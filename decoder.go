@@ -4,38 +4,95 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 )
 
 type DockerLogJson struct {
-	Log string
+	Log    string
+	Stream string
+	// Time is kept raw, so that an unexpected value doesn't fail the whole record
+	Time json.RawMessage
 }
 
+// dockerTimeLayouts are tried in order, the first one is used by Docker
+var dockerTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999"}
+
 type Decoder interface {
 	Decode(string) (string, error)
 }
 
+// EntryDecoder decodes a raw entry into a LogEntry with the metadata found in it (timestamp, stream, level).
+// Zero fields of the decoded entry are filled from the entry received by the parser.
+type EntryDecoder interface {
+	DecodeEntry(string) (LogEntry, error)
+}
+
+// EntryDecoderOf returns d as an EntryDecoder, adapting decoders that only implement Decode.
+func EntryDecoderOf(d Decoder) EntryDecoder {
+	if d == nil {
+		return nil
+	}
+	if ed, ok := d.(EntryDecoder); ok {
+		return ed
+	}
+	return decoderAdapter{d: d}
+}
+
+type decoderAdapter struct {
+	d Decoder
+}
+
+func (a decoderAdapter) DecodeEntry(src string) (LogEntry, error) {
+	content, err := a.d.Decode(src)
+	if err != nil {
+		return LogEntry{}, err
+	}
+	return LogEntry{Content: content}, nil
+}
+
 type DockerJsonDecoder struct{}
 
 func (d DockerJsonDecoder) Decode(src string) (string, error) {
+	entry, err := d.DecodeEntry(src)
+	return entry.Content, err
+}
+
+// DecodeEntry parses a json-file log record: `{"log":"content\n","stream":"stdout","time":"2024-02-16T10:11:12.123456789Z"}`.
+// Docker splits long lines into several records, only the last of which ends with a newline.
+func (d DockerJsonDecoder) DecodeEntry(src string) (LogEntry, error) {
 	obj := DockerLogJson{}
 	if err := json.Unmarshal([]byte(src), &obj); err != nil {
-		return "", fmt.Errorf(`failed to unmarshal docker log entry "%s": %s`, src, err)
+		return LogEntry{}, fmt.Errorf(`failed to unmarshal docker log entry "%s": %s`, src, err)
+	}
+	entry := LogEntry{Content: obj.Log, Stream: obj.Stream, Partial: !strings.HasSuffix(obj.Log, "\n")}
+	var s string
+	if err := json.Unmarshal(obj.Time, &s); err == nil {
+		for _, layout := range dockerTimeLayouts {
+			if ts, err := time.Parse(layout, s); err == nil {
+				entry.Timestamp = ts
+				break
+			}
+		}
 	}
-	return obj.Log, nil
+	return entry, nil
 }
 
 type CriDecoder struct{}
 
 func (d CriDecoder) Decode(src string) (string, error) {
-	c := 0
-	i := strings.IndexFunc(src, func(r rune) bool {
-		if r == ' ' {
-			c++
-		}
-		return c == 3
-	})
-	if i < 0 {
-		return "", fmt.Errorf("unexpected entry format: %s", src)
+	entry, err := d.DecodeEntry(src)
+	return entry.Content, err
+}
+
+// DecodeEntry parses the CRI log format: `2016-10-06T00:17:09.669794202Z stdout F content`
+func (d CriDecoder) DecodeEntry(src string) (LogEntry, error) {
+	parts := strings.SplitN(src, " ", 4)
+	if len(parts) < 4 {
+		return LogEntry{}, fmt.Errorf("unexpected entry format: %s", src)
+	}
+	entry := LogEntry{Content: parts[3], Stream: parts[1], Partial: parts[2] == "P"}
+	if ts, err := time.Parse(time.RFC3339Nano, parts[0]); err == nil {
+		entry.Timestamp = ts
 	}
-	return src[i+1:], nil
+	return entry, nil
 }
@@ -0,0 +1,64 @@
+package logparser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerJsonDecoder(t *testing.T) {
+	d := DockerJsonDecoder{}
+	entry, err := d.DecodeEntry(`{"log":"Server started\n","stream":"stdout","time":"2024-02-16T10:11:12.123456789Z"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "Server started\n", entry.Content)
+	assert.Equal(t, "stdout", entry.Stream)
+	assert.Equal(t, time.Date(2024, 2, 16, 10, 11, 12, 123456789, time.UTC), entry.Timestamp)
+	assert.False(t, entry.Partial)
+
+	entry, err = d.DecodeEntry(`{"log":"Server sta","stream":"stdout","time":"2024-02-16T10:11:12.123456789Z"}`)
+	require.NoError(t, err)
+	assert.True(t, entry.Partial)
+
+	content, err := d.Decode(`{"log":"Server started\n","stream":"stdout","time":"2024-02-16T10:11:12.123456789Z"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "Server started\n", content)
+
+	for _, ts := range []string{`""`, `1708078272`, `null`, `"yesterday"`} {
+		entry, err = d.DecodeEntry(`{"log":"Server started\n","stream":"stdout","time":` + ts + `}`)
+		require.NoError(t, err, ts)
+		assert.Equal(t, "Server started\n", entry.Content)
+		assert.True(t, entry.Timestamp.IsZero(), ts)
+	}
+	entry, err = d.DecodeEntry(`{"log":"Server started\n"}`)
+	require.NoError(t, err)
+	assert.True(t, entry.Timestamp.IsZero())
+	entry, err = d.DecodeEntry(`{"log":"Server started\n","time":"2024-02-16 10:11:12"}`)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 2, 16, 10, 11, 12, 0, time.UTC), entry.Timestamp)
+
+	_, err = d.DecodeEntry(`Server started`)
+	assert.Error(t, err)
+}
+
+func TestCriDecoder(t *testing.T) {
+	d := CriDecoder{}
+	entry, err := d.DecodeEntry(`2016-10-06T00:17:09.669794202Z stderr F ERROR failed to connect`)
+	require.NoError(t, err)
+	assert.Equal(t, "ERROR failed to connect", entry.Content)
+	assert.Equal(t, "stderr", entry.Stream)
+	assert.False(t, entry.Partial)
+	assert.Equal(t, time.Date(2016, 10, 6, 0, 17, 9, 669794202, time.UTC), entry.Timestamp)
+
+	entry, err = d.DecodeEntry(`2016-10-06T00:17:09.669794202Z stdout P ERROR failed`)
+	require.NoError(t, err)
+	assert.True(t, entry.Partial)
+
+	content, err := d.Decode(`2016-10-06T00:17:09.669794202Z stdout F hello world`)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", content)
+
+	_, err = d.DecodeEntry(`2016-10-06T00:17:09.669794202Z stdout`)
+	assert.Error(t, err)
+}
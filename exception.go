@@ -0,0 +1,180 @@
+package logparser
+
+import (
+	"regexp"
+	"strings"
+)
+
+type Language int
+
+const (
+	LanguageUnknown Language = iota
+	LanguageJava
+	LanguagePython
+	LanguageGo
+	LanguageNodeJS
+	LanguageDotNet
+	LanguageRuby
+	LanguagePHP
+)
+
+func (l Language) String() string {
+	switch l {
+	case LanguageJava:
+		return "java"
+	case LanguagePython:
+		return "python"
+	case LanguageGo:
+		return "go"
+	case LanguageNodeJS:
+		return "nodejs"
+	case LanguageDotNet:
+		return "dotnet"
+	case LanguageRuby:
+		return "ruby"
+	case LanguagePHP:
+		return "php"
+	}
+	return "unknown"
+}
+
+type Exception struct {
+	Language Language
+	Type     string
+	Message  string
+}
+
+var (
+	exceptionRe       = regexp.MustCompile(`(?:^|\s)((?:[A-Za-z_$][\w$]*\.)*(?:[A-Za-z_$][\w$]*)?(?:Exception|Error|Throwable))(?: \[\w+\])?(?::\s*(.*))?$`)
+	pythonExceptionRe = regexp.MustCompile(`^((?:[A-Za-z_]\w*\.)*[A-Za-z_]\w*)(?::\s*(.*))?$`)
+	rubyExceptionRe   = regexp.MustCompile(`:in [` + "`" + `'][^']*': (.*) \(([\w:]+)\)$`)
+	phpExceptionRe    = regexp.MustCompile(`Uncaught ([\w\\]+)(?::\s*(.*?))?(?: in \S+:\d+)?$`)
+	// #0 /var/www/index.php(7): foo(), #1 [internal function]: bar(), #2 {main}
+	phpFrameLineRe = regexp.MustCompile(`^#\d+ (?:\S.*\(\d+\): |\[internal function\]: |\{main\}$)`)
+)
+
+// DetectException recognizes the stack trace dialect of a multiline message
+// and extracts the type and the message of the (outermost) exception.
+func DetectException(content string) *Exception {
+	if !strings.Contains(content, "\n") {
+		return nil
+	}
+	lines := strings.Split(content, "\n")
+	e := &Exception{Language: detectStackTraceLanguage(lines)}
+	switch e.Language {
+	case LanguageUnknown:
+		return nil
+	case LanguagePython:
+		e.Type, e.Message = pythonException(lines)
+	case LanguageGo:
+		e.Type, e.Message = goPanic(lines)
+	case LanguageRuby:
+		if m := rubyExceptionRe.FindStringSubmatch(lines[0]); m != nil {
+			e.Type, e.Message = m[2], m[1]
+		}
+	case LanguagePHP:
+		if m := phpExceptionRe.FindStringSubmatch(lines[0]); m != nil {
+			e.Type, e.Message = m[1], m[2]
+		}
+	default:
+		for _, l := range lines {
+			if isStackFrame(l) {
+				break
+			}
+			if m := exceptionRe.FindStringSubmatch(l); m != nil {
+				e.Type, e.Message = m[1], m[2]
+				break
+			}
+		}
+	}
+	return e
+}
+
+func detectStackTraceLanguage(lines []string) Language {
+	res := LanguageUnknown
+	for i, l := range lines {
+		t := strings.TrimLeft(l, " \t")
+		switch {
+		case strings.Contains(l, "Traceback (most recent call last):"):
+			return LanguagePython
+		case strings.HasPrefix(l, "goroutine ") && strings.HasSuffix(l, "]:"):
+			return LanguageGo
+		case strings.HasPrefix(t, "at "):
+			switch {
+			case strings.Contains(t, ".java:") || strings.HasSuffix(t, "(Unknown Source)") || strings.HasSuffix(t, "(Native Method)"):
+				return LanguageJava
+			case strings.Contains(t, ":line "):
+				return LanguageDotNet
+			case strings.Contains(t, ".js:") || strings.Contains(t, ".ts:") || strings.Contains(t, ".mjs:") || strings.Contains(t, ".cjs:") || strings.Contains(t, "(node:") || strings.Contains(t, "(<anonymous>)"):
+				return LanguageNodeJS
+			}
+			if res == LanguageUnknown {
+				switch {
+				case strings.HasPrefix(l, "\t") && javaFrameRe.MatchString(l):
+					res = LanguageJava
+				case strings.HasPrefix(l, "   at ") && dotnetFrameRe.MatchString(l):
+					res = LanguageDotNet
+				case nodeFrameRe.MatchString(l):
+					res = LanguageNodeJS
+				}
+			}
+		case strings.HasPrefix(t, "---> ") || strings.HasPrefix(t, "--- End of "):
+			return LanguageDotNet
+		case strings.HasPrefix(t, "from ") && strings.Contains(t, ".rb:"):
+			return LanguageRuby
+		case i == 0 && strings.Contains(l, ".rb:") && strings.Contains(l, ":in "):
+			return LanguageRuby
+		case l == "Stack trace:" || l == "PHP Stack trace:" || isPhpFrame(l):
+			return LanguagePHP
+		}
+	}
+	return res
+}
+
+func isStackFrame(l string) bool {
+	return strings.HasPrefix(strings.TrimLeft(l, " \t"), "at ")
+}
+
+// the exception is reported at the end of the (last) traceback:
+// KeyError: 'foo'
+func pythonException(lines []string) (string, string) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		l := lines[i]
+		if l == "" || l[0] == ' ' || l[0] == '\t' {
+			continue
+		}
+		if m := pythonExceptionRe.FindStringSubmatch(l); m != nil {
+			return m[1], m[2]
+		}
+		return "", ""
+	}
+	return "", ""
+}
+
+// panic: runtime error: invalid memory address or nil pointer dereference
+// http: panic serving 127.0.0.1:56889: runtime error: invalid memory address or nil pointer dereference
+// fatal error: concurrent map writes
+func goPanic(lines []string) (string, string) {
+	for _, l := range lines {
+		var value string
+		switch {
+		case strings.HasPrefix(l, "fatal error: "):
+			return "fatal error", strings.TrimPrefix(l, "fatal error: ")
+		case strings.Contains(l, "panic: "):
+			value = l[strings.Index(l, "panic: ")+len("panic: "):]
+		case strings.Contains(l, "panic serving "):
+			value = l[strings.Index(l, "panic serving ")+len("panic serving "):]
+			if i := strings.Index(value, ": "); i >= 0 {
+				value = value[i+2:]
+			}
+		default:
+			continue
+		}
+		value = strings.TrimSuffix(value, " [recovered]")
+		if strings.HasPrefix(value, "runtime error: ") {
+			return "runtime error", strings.TrimPrefix(value, "runtime error: ")
+		}
+		return "panic", value
+	}
+	return "", ""
+}
@@ -0,0 +1,96 @@
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectException(t *testing.T) {
+	check := func(content string, language Language, typ, message string) {
+		t.Helper()
+		e := DetectException(content)
+		if language == LanguageUnknown {
+			assert.Nil(t, e)
+			return
+		}
+		if assert.NotNil(t, e) {
+			assert.Equal(t, Exception{Language: language, Type: typ, Message: message}, *e)
+		}
+	}
+
+	check(`Exception in thread "main" java.lang.NullPointerException
+	at com.example.MyClass.methodA(MyClass.java:10)
+Caused by: java.lang.ArrayIndexOutOfBoundsException: Index 5 out of bounds for length 5
+	at com.example.AnotherClass.anotherMethod(AnotherClass.java:15)
+	... 2 more`, LanguageJava, "java.lang.NullPointerException", "")
+
+	check(`ERROR [Messaging-EventLoop-3-1] 2023-10-04 14:27:35,249 javax.servlet.ServletException: Something bad happened
+	at com.example.myproject.OpenSessionInViewFilter.doFilter(OpenSessionInViewFilter.java:60)
+	at org.mortbay.jetty.servlet.ServletHandler$CachedChain.doFilter(ServletHandler.java:1157)`, LanguageJava, "javax.servlet.ServletException", "Something bad happened")
+
+	check(`2020-03-20 08:48:57,067 ERROR:__main__:Traceback (most recent call last):
+  File "<stdin>", line 2, in <module>
+  File "<stdin>", line 2, in raise_error
+RuntimeError: something bad happened!`, LanguagePython, "RuntimeError", "something bad happened!")
+
+	check(`Traceback (most recent call last):
+  File "/app/main.py", line 4, in func
+    d["foo"]
+KeyError: 'foo'
+
+During handling of the above exception, another exception occurred:
+
+Traceback (most recent call last):
+  File "/app/main.py", line 14, in <module>
+    raise ConnectionError
+ConnectionError`, LanguagePython, "ConnectionError", "")
+
+	check(`panic: runtime error: invalid memory address or nil pointer dereference
+[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x47e8c2]
+
+goroutine 1 [running]:
+main.main()
+	/app/main.go:9 +0x22`, LanguageGo, "runtime error", "invalid memory address or nil pointer dereference")
+
+	check(`2024/02/16 15:01:22 http: panic serving 127.0.0.1:56889: runtime error: index out of range [5] with length 3
+goroutine 675 [running]:
+net/http.(*conn).serve.func1()
+        /usr/local/go/src/net/http/server.go:1868 +0xb0`, LanguageGo, "runtime error", "index out of range [5] with length 3")
+
+	check(`panic: something went wrong [recovered]
+	panic: something went wrong
+
+goroutine 7 [running]:
+testing.tRunner.func1.2({0x5d6f60, 0x6a4c30})`, LanguageGo, "panic", "something went wrong")
+
+	check(`UnauthorizedException [Error]: jwt expired
+    at AuthMixingGuard.canActivate (/app/dist/core/auth.guard.js:23:27)
+    at GuardsConsumer.tryActivate (/app/node_modules/@nestjs/core/guards/guards-consumer.js:15:34)`, LanguageNodeJS, "UnauthorizedException", "jwt expired")
+
+	check(`TypeError: Cannot read properties of undefined (reading 'id')
+    at getUser (file:///app/src/users.mjs:12:20)
+    at process.processTicksAndRejections (node:internal/process/task_queues:95:5)`, LanguageNodeJS, "TypeError", "Cannot read properties of undefined (reading 'id')")
+
+	check(`Unhandled exception. System.InvalidOperationException: Failed to load the order
+ ---> System.Data.SqlClient.SqlException: Timeout expired.
+   at Orders.Repository.Load(Int32 id) in /src/Orders/Repository.cs:line 42
+   --- End of inner exception stack trace ---`, LanguageDotNet, "System.InvalidOperationException", "Failed to load the order")
+
+	check(`/app/lib/worker.rb:12:in 'process': undefined method 'name' for nil (NoMethodError)
+	from /app/lib/worker.rb:5:in 'block in run'
+	from /app/bin/worker:8:in '<main>'`, LanguageRuby, "NoMethodError", "undefined method 'name' for nil")
+
+	check(`PHP Fatal error:  Uncaught Exception: Database is unavailable in /var/www/html/src/Db.php:21
+Stack trace:
+#0 /var/www/html/index.php(7): App\Db->connect()
+#1 {main}
+  thrown in /var/www/html/src/Db.php on line 21`, LanguagePHP, "Exception", "Database is unavailable")
+
+	check(`java.lang.IllegalStateException: single line`, LanguageUnknown, "", "")
+	check("line one\nline two", LanguageUnknown, "", "")
+	check("Deployment plan:\n#1 drain the node\n#2 upgrade the kernel", LanguageUnknown, "", "")
+	check("Maintenance window:\n  at 10:00 we restart the database", LanguageUnknown, "", "")
+	check("Some text\n\tat the office", LanguageUnknown, "", "")
+	check("Some text\n   at the office", LanguageUnknown, "", "")
+}
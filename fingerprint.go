@@ -0,0 +1,69 @@
+package logparser
+
+import (
+	"regexp"
+)
+
+const (
+	defaultFingerprintFrames = 5
+)
+
+var (
+	generatedAccessorRe = regexp.MustCompile(`(Generated\w*Accessor|\$Proxy|\$\$Lambda\$?)[\d/x]+[0-9a-f]*|\$\$(EnhancerBy\w+|FastClassBy\w+)\$\$[0-9a-f]+`)
+)
+
+// stackTraceFingerprint builds the identity of an exception from its type and the innermost in-app frames,
+// ignoring line numbers and the suffixes of generated classes, so that exceptions that differ only in their
+// messages are grouped together.
+func stackTraceFingerprint(content string, e *Exception, frames int, inAppPrefixes []string) *Pattern {
+	if e == nil || e.Type == "" {
+		return nil
+	}
+	st := ParseStackTrace(content)
+	if st == nil || len(st.Exceptions) == 0 {
+		return nil
+	}
+	all := st.Exceptions[0].Frames
+	if len(all) == 0 {
+		for _, ce := range st.Exceptions[1:] {
+			all = append(all, ce.Frames...)
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	var top []string
+	for _, f := range all {
+		inApp := f.InApp
+		if len(inAppPrefixes) > 0 {
+			inApp = isInAppFrame(st.Language, f, inAppPrefixes)
+		}
+		if inApp {
+			top = append(top, normalizeFrame(f))
+			if len(top) >= frames {
+				break
+			}
+		}
+	}
+	if len(top) == 0 {
+		for _, f := range all {
+			top = append(top, normalizeFrame(f))
+			if len(top) >= frames {
+				break
+			}
+		}
+	}
+	return &Pattern{words: append([]string{e.Type}, top...), exact: true}
+}
+
+func normalizeFrame(f Frame) string {
+	function := generatedAccessorRe.ReplaceAllString(f.qualifiedFunction(), "$1$2")
+	file := generatedAccessorRe.ReplaceAllString(f.File, "$1$2")
+	if file == "" {
+		return function
+	}
+	if function == "" {
+		return file
+	}
+	return file + ":" + function
+}
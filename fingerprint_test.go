@@ -0,0 +1,91 @@
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fingerprint(content string, inAppPrefixes ...string) *Pattern {
+	return stackTraceFingerprint(content, DetectException(content), 3, inAppPrefixes)
+}
+
+func TestStackTraceFingerprintJava(t *testing.T) {
+	e1 := `java.lang.ArrayIndexOutOfBoundsException: Index 5 out of bounds for length 5
+	at com.example.AnotherClass.anotherMethod(AnotherClass.java:15)
+	at sun.reflect.GeneratedMethodAccessor72.invoke(Unknown Source)
+	at java.lang.reflect.Method.invoke(Method.java:597)
+	at com.example.MyClass.methodA(MyClass.java:8)
+	at com.example.MyClass.main(MyClass.java:30)
+	at com.example.MyClass.unreached(MyClass.java:40)`
+	e2 := `java.lang.ArrayIndexOutOfBoundsException: Index 7 out of bounds for length 5
+	at com.example.AnotherClass.anotherMethod(AnotherClass.java:16)
+	at sun.reflect.GeneratedMethodAccessor5.invoke(Unknown Source)
+	at java.lang.reflect.Method.invoke(Method.java:597)
+	at com.example.MyClass.methodA(MyClass.java:9)
+	at com.example.MyClass.main(MyClass.java:30)`
+	e3 := `java.lang.IllegalStateException: Index 5 out of bounds for length 5
+	at com.example.AnotherClass.anotherMethod(AnotherClass.java:15)
+	at com.example.MyClass.methodA(MyClass.java:8)
+	at com.example.MyClass.main(MyClass.java:30)`
+
+	p1, p2, p3 := fingerprint(e1), fingerprint(e2), fingerprint(e3)
+	require.NotNil(t, p1)
+	assert.Equal(t, "java.lang.ArrayIndexOutOfBoundsException AnotherClass.java:com.example.AnotherClass.anotherMethod MyClass.java:com.example.MyClass.methodA MyClass.java:com.example.MyClass.main", p1.String())
+	assert.Equal(t, p1.Hash(), p2.Hash())
+	assert.NotEqual(t, p1.Hash(), p3.Hash())
+
+	p := fingerprint(e1, "sun.", "com.example.MyClass")
+	assert.Equal(t, "java.lang.ArrayIndexOutOfBoundsException Unknown Source:sun.reflect.GeneratedMethodAccessor.invoke MyClass.java:com.example.MyClass.methodA MyClass.java:com.example.MyClass.main", p.String())
+}
+
+func TestStackTraceFingerprintGo(t *testing.T) {
+	p := fingerprint(`2024/02/16 15:01:22 http: panic serving 127.0.0.1:56889: runtime error: invalid memory address or nil pointer dereference
+goroutine 675 [running]:
+net/http.(*conn).serve.func1()
+        /usr/local/go/src/net/http/server.go:1868 +0xb0
+panic({0x103383820?, 0x103ba2fa0?})
+        /usr/local/go/src/runtime/panic.go:920 +0x26c
+github.com/coroot/coroot/api.(*Api).App(0x1034cd180?, {0x1034cab30, 0x1400239a0e0}, 0x1032707e0?)
+        /app/api/api.go:377 +0x228
+net/http.HandlerFunc.ServeHTTP(0x140030e0800?, {0x1034cab30?, 0x1400239a0e0?}, 0x0?)
+        /usr/local/go/src/net/http/server.go:2136 +0x38
+github.com/gorilla/mux.(*Router).ServeHTTP(0x140000fa180, {0x1034cab30, 0x1400239a0e0}, 0x140030e0600)
+        /go/pkg/mod/github.com/gorilla/mux@v1.8.0/mux.go:210 +0x194
+created by net/http.(*Server).Serve in goroutine 1
+        /usr/local/go/src/net/http/server.go:3086 +0x4cc`)
+	require.NotNil(t, p)
+	assert.Equal(t, "runtime error /app/api/api.go:github.com/coroot/coroot/api.(*Api).App", p.String())
+}
+
+func TestStackTraceFingerprintPython(t *testing.T) {
+	p := fingerprint(`Traceback (most recent call last):
+  File "/usr/local/lib/python3.8/site-packages/django/core/handlers/exception.py", line 34, in inner
+    response = get_response(request)
+  File "/app/views.py", line 12, in article
+    return render(request, load(id))
+KeyError: 'article-42'`)
+	require.NotNil(t, p)
+	assert.Equal(t, "KeyError /app/views.py:article", p.String())
+
+	// the innermost frames identify the exception, the outer ones are shared by all the handlers
+	trace := func(handler, function string) string {
+		return `Traceback (most recent call last):
+  File "/app/main.py", line 40, in <module>
+    serve()
+  File "/app/main.py", line 30, in serve
+    loop()
+  File "/app/server.py", line 20, in loop
+    dispatch(request)
+  File "/app/handlers/` + handler + `", line 7, in ` + function + `
+    return cache[key]
+KeyError: 'id'`
+	}
+	users, orders := fingerprint(trace("users.py", "get_user")), fingerprint(trace("orders.py", "get_order"))
+	require.NotNil(t, users)
+	assert.Equal(t, "KeyError /app/handlers/users.py:get_user /app/server.py:loop /app/main.py:serve", users.String())
+	assert.NotEqual(t, users.Hash(), orders.Hash())
+
+	assert.Nil(t, fingerprint("just a message"))
+}
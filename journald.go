@@ -0,0 +1,184 @@
+package logparser
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	JournaldLabelUnit = "unit"
+
+	// journaldFieldLimit protects from allocating memory for the corrupted binary field sizes
+	journaldFieldLimit = 16 * 1024 * 1024
+)
+
+// journaldEntry maps the journal fields to a LogEntry:
+// MESSAGE, PRIORITY, __REALTIME_TIMESTAMP (µs), _SYSTEMD_UNIT, _HOSTNAME, SYSLOG_IDENTIFIER and _PID
+func journaldEntry(fields map[string]string) (LogEntry, error) {
+	entry := LogEntry{Content: fields["MESSAGE"], Level: LevelByPriority(fields["PRIORITY"]), Labels: map[string]string{}}
+	if v := fields["__REALTIME_TIMESTAMP"]; v != "" {
+		us, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return LogEntry{}, fmt.Errorf("invalid __REALTIME_TIMESTAMP: %s", v)
+		}
+		entry.Timestamp = time.UnixMicro(us)
+	}
+	for label, field := range map[string]string{
+		JournaldLabelUnit: "_SYSTEMD_UNIT",
+		SyslogLabelHost:   "_HOSTNAME",
+		SyslogLabelApp:    "SYSLOG_IDENTIFIER",
+		SyslogLabelProcID: "_PID",
+	} {
+		if v := fields[field]; v != "" {
+			entry.Labels[label] = v
+		}
+	}
+	return entry, nil
+}
+
+// JournaldJsonDecoder parses the entries printed by `journalctl -o json`.
+type JournaldJsonDecoder struct{}
+
+func (d JournaldJsonDecoder) Decode(src string) (string, error) {
+	entry, err := d.DecodeEntry(src)
+	return entry.Content, err
+}
+
+func (d JournaldJsonDecoder) DecodeEntry(src string) (LogEntry, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(src), &obj); err != nil {
+		return LogEntry{}, fmt.Errorf(`failed to unmarshal journald entry "%s": %s`, src, err)
+	}
+	fields := make(map[string]string, len(obj))
+	for k, v := range obj {
+		fields[k] = journaldJsonValue(v)
+	}
+	return journaldEntry(fields)
+}
+
+// journaldJsonValue decodes a field value: a string, an array of bytes (non-UTF-8 or binary data), null (too large values)
+// or an array of values if the field is repeated (the first one is used).
+func journaldJsonValue(v json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil {
+		return s
+	}
+	var values []json.RawMessage
+	if err := json.Unmarshal(v, &values); err != nil || len(values) == 0 {
+		return ""
+	}
+	var b []byte
+	for _, value := range values {
+		n, err := strconv.ParseUint(string(value), 10, 8)
+		if err != nil {
+			return journaldJsonValue(values[0])
+		}
+		b = append(b, byte(n))
+	}
+	return string(b)
+}
+
+// JournaldExportDecoder parses the records of the `journalctl -o export` stream read by JournaldExportReader.
+type JournaldExportDecoder struct{}
+
+func (d JournaldExportDecoder) Decode(src string) (string, error) {
+	entry, err := d.DecodeEntry(src)
+	return entry.Content, err
+}
+
+// DecodeEntry parses a record: `KEY=value` lines, a binary field is the name line followed by
+// the 64-bit little endian size of the value, the value and a newline.
+func (d JournaldExportDecoder) DecodeEntry(src string) (LogEntry, error) {
+	fields := map[string]string{}
+	for s := src; s != ""; {
+		end := strings.IndexByte(s, '\n')
+		if end < 0 {
+			end = len(s)
+		}
+		line := s[:end]
+		if k, v, ok := strings.Cut(line, "="); ok {
+			fields[k] = v
+			s = s[min(end+1, len(s)):]
+			continue
+		}
+		if line == "" {
+			s = s[min(end+1, len(s)):]
+			continue
+		}
+		if end == len(s) {
+			return LogEntry{}, fmt.Errorf("invalid journald export record: truncated binary field %s", line)
+		}
+		s = s[end+1:]
+		if len(s) < 8 {
+			return LogEntry{}, fmt.Errorf("invalid journald export record: truncated binary field %s", line)
+		}
+		size := binary.LittleEndian.Uint64([]byte(s[:8]))
+		if size > uint64(len(s)-8) {
+			return LogEntry{}, fmt.Errorf("invalid journald export record: truncated binary field %s", line)
+		}
+		fields[line] = s[8 : 8+size]
+		s = strings.TrimPrefix(s[8+size:], "\n")
+	}
+	return journaldEntry(fields)
+}
+
+// JournaldExportReader splits the `journalctl -o export` stream into records separated by empty lines.
+type JournaldExportReader struct {
+	r *bufio.Reader
+}
+
+func NewJournaldExportReader(r io.Reader) *JournaldExportReader {
+	return &JournaldExportReader{r: bufio.NewReader(r)}
+}
+
+// ReadRecord returns the next record to be decoded by JournaldExportDecoder or io.EOF.
+func (r *JournaldExportReader) ReadRecord() (string, error) {
+	var record strings.Builder
+	for {
+		line, err := r.r.ReadString('\n')
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return "", err
+			}
+			if strings.IndexByte(line, '=') >= 0 { // the last line without the trailing newline
+				record.WriteString(line)
+			}
+			if record.Len() > 0 {
+				return record.String(), nil
+			}
+			return "", io.EOF
+		}
+		if line == "\n" {
+			if record.Len() > 0 {
+				return record.String(), nil
+			}
+			continue
+		}
+		record.WriteString(line)
+		if strings.IndexByte(line, '=') >= 0 {
+			continue
+		}
+		// a binary field: the size and the value follow the name
+		var size [8]byte
+		if _, err = io.ReadFull(r.r, size[:]); err != nil {
+			return "", io.ErrUnexpectedEOF
+		}
+		n := binary.LittleEndian.Uint64(size[:])
+		if n > journaldFieldLimit {
+			return "", fmt.Errorf("journald field %s is too large: %d", strings.TrimSuffix(line, "\n"), n)
+		}
+		value := make([]byte, n+1)
+		if _, err = io.ReadFull(r.r, value); err != nil {
+			return "", io.ErrUnexpectedEOF
+		}
+		record.Write(size[:])
+		record.Write(value)
+	}
+}
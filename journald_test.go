@@ -0,0 +1,102 @@
+package logparser
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournaldJsonDecoder(t *testing.T) {
+	d := JournaldJsonDecoder{}
+	entry, err := d.DecodeEntry(`{"__CURSOR":"s=1;i=2","__REALTIME_TIMESTAMP":"1700000000123456","__MONOTONIC_TIMESTAMP":"123","PRIORITY":"3","_SYSTEMD_UNIT":"nginx.service","_PID":"1234","_HOSTNAME":"web-1","SYSLOG_IDENTIFIER":"nginx","MESSAGE":"connect() failed (111: Connection refused)"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "connect() failed (111: Connection refused)", entry.Content)
+	assert.Equal(t, LevelError, entry.Level)
+	assert.Equal(t, time.UnixMicro(1700000000123456), entry.Timestamp)
+	assert.Equal(t, map[string]string{"unit": "nginx.service", "procid": "1234", "host": "web-1", "app": "nginx"}, entry.Labels)
+
+	// non-UTF-8 messages are printed as arrays of bytes, repeated fields as arrays of values
+	entry, err = d.DecodeEntry(`{"__REALTIME_TIMESTAMP":"1700000000000000","PRIORITY":"6","MESSAGE":[104,105,255],"_SYSTEMD_UNIT":["a.service","b.service"]}`)
+	require.NoError(t, err)
+	assert.Equal(t, "hi\xff", entry.Content)
+	assert.Equal(t, LevelInfo, entry.Level)
+	assert.Equal(t, "a.service", entry.Labels["unit"])
+
+	entry, err = d.DecodeEntry(`{"MESSAGE":null}`)
+	require.NoError(t, err)
+	assert.Equal(t, "", entry.Content)
+	assert.Equal(t, LevelUnknown, entry.Level)
+	assert.True(t, entry.Timestamp.IsZero())
+
+	_, err = d.DecodeEntry(`{"__REALTIME_TIMESTAMP":"yesterday","MESSAGE":"hello"}`)
+	assert.Error(t, err)
+	_, err = d.DecodeEntry(`MESSAGE=hello`)
+	assert.Error(t, err)
+}
+
+func binaryField(name, value string) string {
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, uint64(len(value)))
+	return name + "\n" + string(size) + value + "\n"
+}
+
+func TestJournaldExport(t *testing.T) {
+	stream := "__CURSOR=s=1;i=1\n" +
+		"__REALTIME_TIMESTAMP=1700000000123456\n" +
+		"PRIORITY=4\n" +
+		"_SYSTEMD_UNIT=app.service\n" +
+		"_PID=42\n" +
+		binaryField("MESSAGE", "panic: oops\n\ngoroutine 1 [running]:\nmain.main()") +
+		"SYSLOG_IDENTIFIER=app\n" +
+		"\n" +
+		"__REALTIME_TIMESTAMP=1700000001000000\n" +
+		"PRIORITY=6\n" +
+		"MESSAGE=Started app.service.\n" +
+		"\n" +
+		"__REALTIME_TIMESTAMP=1700000002000000\n" +
+		"MESSAGE=last record without the trailing newline"
+
+	r := NewJournaldExportReader(strings.NewReader(stream))
+	d := JournaldExportDecoder{}
+
+	record, err := r.ReadRecord()
+	require.NoError(t, err)
+	entry, err := d.DecodeEntry(record)
+	require.NoError(t, err)
+	assert.Equal(t, "panic: oops\n\ngoroutine 1 [running]:\nmain.main()", entry.Content)
+	assert.Equal(t, LevelWarning, entry.Level)
+	assert.Equal(t, time.UnixMicro(1700000000123456), entry.Timestamp)
+	assert.Equal(t, map[string]string{"unit": "app.service", "procid": "42", "app": "app"}, entry.Labels)
+
+	record, err = r.ReadRecord()
+	require.NoError(t, err)
+	entry, err = d.DecodeEntry(record)
+	require.NoError(t, err)
+	assert.Equal(t, "Started app.service.", entry.Content)
+	assert.Equal(t, LevelInfo, entry.Level)
+
+	record, err = r.ReadRecord()
+	require.NoError(t, err)
+	content, err := d.Decode(record)
+	require.NoError(t, err)
+	assert.Equal(t, "last record without the trailing newline", content)
+
+	_, err = r.ReadRecord()
+	assert.Equal(t, io.EOF, err)
+
+	r = NewJournaldExportReader(strings.NewReader("PRIORITY=4\nMESSAGE\n\x10\x00"))
+	_, err = r.ReadRecord()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	r = NewJournaldExportReader(strings.NewReader("MESSAGE\n\xff\xff\xff\xff\xff\xff\xff\xff"))
+	_, err = r.ReadRecord()
+	assert.Error(t, err)
+
+	_, err = d.DecodeEntry("PRIORITY=4\nMESSAGE\n\x10\x00\x00\x00\x00\x00\x00\x00hello\n")
+	assert.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package logparser
+
+import (
+	"strings"
+)
+
+const (
+	maxContentLenForKeywords = 1024
+)
+
+var (
+	defaultErrorKeywords = []string{
+		"failed", "failure", "fails", "unable to", "cannot", "can't", "could not", "couldn't", "not able to",
+		"exception", "traceback", "panic", "crashed", "aborted", "aborting",
+		"connection refused", "connection reset", "broken pipe", "no route to host", "network is unreachable",
+		"host is unreachable", "timed out", "i/o timeout", "deadline exceeded",
+		"no such file or directory", "permission denied", "access denied", "operation not permitted",
+		"too many open files", "no space left on device", "out of memory", "cannot allocate memory",
+		"segmentation fault", "core dumped", "killed", "oomkilled",
+	}
+	defaultWarningKeywords = []string{
+		"deprecated", "deprecation", "retrying", "retry", "falling back", "fallback", "skipping", "ignoring",
+		"throttled", "throttling", "rate limited", "slow query", "took too long", "degraded", "unhealthy",
+	}
+	exceptionSuffixes = []string{"exception", "error"}
+)
+
+// KeywordClassifier assigns a heuristic level to messages without an explicit one
+// by looking for well-known failure verbs, exception class names and errno strings.
+type KeywordClassifier struct {
+	keywords map[Level][]string
+}
+
+// NewKeywordClassifier returns a classifier with the built-in dictionary, which can be extended with Add.
+func NewKeywordClassifier() *KeywordClassifier {
+	c := &KeywordClassifier{keywords: map[Level][]string{}}
+	c.Add(LevelError, defaultErrorKeywords...)
+	c.Add(LevelWarning, defaultWarningKeywords...)
+	return c
+}
+
+// Add registers case-insensitive keywords or phrases that must be matched as whole words.
+func (c *KeywordClassifier) Add(level Level, keywords ...string) {
+	for _, k := range keywords {
+		c.keywords[level] = append(c.keywords[level], strings.ToLower(k))
+	}
+}
+
+// Classify returns the most severe level whose keywords are found in the content.
+func (c *KeywordClassifier) Classify(content string) Level {
+	if len(content) > maxContentLenForKeywords {
+		content = content[:maxContentLenForKeywords]
+	}
+	content = strings.ToLower(content)
+	for _, level := range []Level{LevelEmergency, LevelAlert, LevelCritical, LevelError, LevelWarning, LevelNotice, LevelInfo, LevelDebug, LevelTrace} {
+		for _, k := range c.keywords[level] {
+			if containsWord(content, k) {
+				return level
+			}
+		}
+		if level == LevelError && containsExceptionName(content) {
+			return level
+		}
+	}
+	return LevelUnknown
+}
+
+func containsWord(s, word string) bool {
+	for offset := 0; offset < len(s); {
+		i := strings.Index(s[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(word)
+		if (start == 0 || !isWordChar(s[start-1])) && (end == len(s) || !isWordChar(s[end])) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+// OutOfMemoryError, java.net.ConnectException, KeyError
+func containsExceptionName(s string) bool {
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r > 127 || !isWordChar(byte(r)) && r != '.' && r != '$' }) {
+		f = strings.TrimRight(f, ".")
+		for _, suffix := range exceptionSuffixes {
+			if len(f) > len(suffix) && strings.HasSuffix(f, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_'
+}
@@ -0,0 +1,26 @@
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeywordClassifier(t *testing.T) {
+	c := NewKeywordClassifier()
+	assert.Equal(t, LevelError, c.Classify("Failed to connect to db"))
+	assert.Equal(t, LevelError, c.Classify("dial tcp 10.0.0.1:5432: connect: connection refused"))
+	assert.Equal(t, LevelError, c.Classify("java.lang.OutOfMemoryError: Java heap space"))
+	assert.Equal(t, LevelError, c.Classify("open /data/db.lock: no such file or directory"))
+	assert.Equal(t, LevelError, c.Classify("Got ConnectException while talking to the broker"))
+	assert.Equal(t, LevelWarning, c.Classify("config option 'foo' is deprecated, use 'bar' instead"))
+	assert.Equal(t, LevelWarning, c.Classify("Retrying request to https://example.com in 5s"))
+	assert.Equal(t, LevelError, c.Classify("Retrying request: connection reset by peer"))
+
+	assert.Equal(t, LevelUnknown, c.Classify("GET /api/failedjobs 200"))
+	assert.Equal(t, LevelUnknown, c.Classify("Server started on port 8080"))
+	assert.Equal(t, LevelUnknown, c.Classify("errors: 0"))
+
+	c.Add(LevelCritical, "Data Corruption")
+	assert.Equal(t, LevelCritical, c.Classify("possible data corruption detected, failed to verify checksum"))
+}
@@ -0,0 +1,92 @@
+package logparser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// <3>[12345.678901] or [12345.678901]
+	kmsgMonotonicRe = regexp.MustCompile(`^(?:<(\d{1,3})>)?\[\s*(\d+)\.(\d{1,9})\] ?`)
+	// 3,1234,12345678901,-; (priority, sequence number, timestamp, flags and optional fields)
+	kmsgDevRe = regexp.MustCompile(`^(\d{1,3}),\d+,(\d+),[-+c](?:,[^;]*)?;`)
+	// [Mon Oct 14 10:00:00 2026]
+	kmsgWallTimeRe = regexp.MustCompile(`^(?:<(\d{1,3})>)?\[([A-Z][a-z]{2} [A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2} \d{4})\] ?`)
+)
+
+// KmsgDecoder parses kernel log lines:
+//
+//	<3>[12345.678901] nvme0: I/O timeout       (dmesg -r)
+//	[12345.678901] nvme0: I/O timeout          (dmesg)
+//	[Mon Oct 14 10:00:00 2026] nvme0: I/O timeout  (dmesg -T)
+//	3,1234,12345678901,-;nvme0: I/O timeout    (/dev/kmsg)
+//
+// The level is derived from the priority prefix. The lines of /dev/kmsg starting with a space
+// (the key=value dictionary of the previous record) are returned as is.
+type KmsgDecoder struct {
+	// BootTime is used to convert the timestamps relative to the boot to wall time; they're left unset if it's zero.
+	BootTime time.Time
+	// Location is the zone of the `dmesg -T` timestamps, time.Local if nil.
+	Location *time.Location
+}
+
+func (d KmsgDecoder) Decode(src string) (string, error) {
+	entry, err := d.DecodeEntry(src)
+	return entry.Content, err
+}
+
+func (d KmsgDecoder) DecodeEntry(src string) (LogEntry, error) {
+	if strings.HasPrefix(src, " ") {
+		return LogEntry{Content: src}, nil
+	}
+	if m := kmsgMonotonicRe.FindStringSubmatch(src); m != nil {
+		frac := m[3] + strings.Repeat("0", 9-len(m[3]))
+		return LogEntry{
+			Timestamp: d.wallTime(atoi(m[2]), atoi(frac)),
+			Level:     kmsgLevel(m[1]),
+			Content:   src[len(m[0]):],
+		}, nil
+	}
+	if m := kmsgDevRe.FindStringSubmatch(src); m != nil {
+		us, _ := strconv.ParseInt(m[2], 10, 64)
+		return LogEntry{
+			Timestamp: d.wallTime(int(us/1e6), int(us%1e6)*1000),
+			Level:     kmsgLevel(m[1]),
+			Content:   src[len(m[0]):],
+		}, nil
+	}
+	if m := kmsgWallTimeRe.FindStringSubmatch(src); m != nil {
+		loc := d.Location
+		if loc == nil {
+			loc = time.Local
+		}
+		ts, err := time.ParseInLocation(time.ANSIC, m[2], loc)
+		if err != nil {
+			return LogEntry{}, fmt.Errorf("invalid kernel log timestamp: %s", src)
+		}
+		return LogEntry{Timestamp: ts, Level: kmsgLevel(m[1]), Content: src[len(m[0]):]}, nil
+	}
+	return LogEntry{}, fmt.Errorf("unexpected kernel log entry format: %s", src)
+}
+
+func (d KmsgDecoder) wallTime(sec, nsec int) time.Time {
+	if d.BootTime.IsZero() {
+		return time.Time{}
+	}
+	return d.BootTime.Add(time.Duration(sec)*time.Second + time.Duration(nsec))
+}
+
+// the priority includes the facility (e.g., 6 is kernel info, 14 is user info)
+func kmsgLevel(priority string) Level {
+	if priority == "" {
+		return LevelUnknown
+	}
+	return LevelByPriority(strconv.Itoa(atoi(priority) % 8))
+}
+
+func isKmsgLine(line string) bool {
+	return kmsgMonotonicRe.MatchString(line) || kmsgDevRe.MatchString(line) || kmsgWallTimeRe.MatchString(line)
+}
@@ -0,0 +1,41 @@
+package logparser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKmsgDecoder(t *testing.T) {
+	boot := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	d := KmsgDecoder{BootTime: boot, Location: time.UTC}
+
+	check := func(src string, ts time.Time, level Level, content string) {
+		t.Helper()
+		entry, err := d.DecodeEntry(src)
+		require.NoError(t, err)
+		assert.True(t, ts.Equal(entry.Timestamp), "expected %s, got %s", ts, entry.Timestamp)
+		assert.Equal(t, level, entry.Level)
+		assert.Equal(t, content, entry.Content)
+	}
+	check(`<3>[12345.678901] nvme0: I/O timeout`, boot.Add(12345678901*time.Microsecond), LevelError, `nvme0: I/O timeout`)
+	check(`<6>[    0.000000] Linux version 6.1.0-13-amd64`, boot, LevelInfo, `Linux version 6.1.0-13-amd64`)
+	check(`[   12.5] EXT4-fs (sda1): mounted filesystem`, boot.Add(12500*time.Millisecond), LevelUnknown, `EXT4-fs (sda1): mounted filesystem`)
+	check(`3,1234,12345678901,-;nvme0: I/O timeout`, boot.Add(12345678901*time.Microsecond), LevelError, `nvme0: I/O timeout`)
+	check(`12,706,5140900,-,caller=T1;systemd[1]: starting`, boot.Add(5140900*time.Microsecond), LevelWarning, `systemd[1]: starting`)
+	check(`[Wed Oct 14 10:00:00 2026] nvme0: I/O timeout`, time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC), LevelUnknown, `nvme0: I/O timeout`)
+	check(`<4>[Sun Oct  4 09:08:07 2026] ata1.00: failed command`, time.Date(2026, 10, 4, 9, 8, 7, 0, time.UTC), LevelWarning, `ata1.00: failed command`)
+	check(` SUBSYSTEM=pci`, time.Time{}, LevelUnknown, ` SUBSYSTEM=pci`)
+
+	entry, err := KmsgDecoder{}.DecodeEntry(`<3>[12345.678901] nvme0: I/O timeout`)
+	require.NoError(t, err)
+	assert.True(t, entry.Timestamp.IsZero())
+
+	for _, src := range []string{`nvme0: I/O timeout`, `[Xyz Oct 14 10:00:00 2026] foo`, `<3> nvme0`, `1,200,3000,ok;done`} {
+		_, err = d.DecodeEntry(src)
+		assert.Error(t, err, src)
+	}
+	assert.False(t, isKmsgLine(`1,200,3000,ok;done`))
+}
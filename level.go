@@ -1,6 +1,8 @@
 package logparser
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 	"unicode"
 )
@@ -14,6 +16,11 @@ const (
 	LevelWarning
 	LevelInfo
 	LevelDebug
+	// fine-grained levels, see Level.Coarse
+	LevelTrace
+	LevelNotice
+	LevelAlert
+	LevelEmergency
 
 	maxLineLenForGuessingLevel = 255
 	guessLevelInFields         = 7
@@ -31,10 +38,107 @@ func (l Level) String() string {
 		return "info"
 	case LevelDebug:
 		return "debug"
+	case LevelTrace:
+		return "trace"
+	case LevelNotice:
+		return "notice"
+	case LevelAlert:
+		return "alert"
+	case LevelEmergency:
+		return "emergency"
 	}
 	return "unknown"
 }
 
+// Coarse maps the fine-grained levels to the original set of Unknown, Critical, Error, Warning, Info and Debug.
+func (l Level) Coarse() Level {
+	switch l {
+	case LevelTrace:
+		return LevelDebug
+	case LevelNotice:
+		return LevelInfo
+	case LevelAlert, LevelEmergency:
+		return LevelCritical
+	}
+	return l
+}
+
+// SeverityNumber returns the OpenTelemetry SeverityNumber of the level.
+func (l Level) SeverityNumber() int {
+	switch l {
+	case LevelTrace:
+		return 1
+	case LevelDebug:
+		return 5
+	case LevelInfo:
+		return 9
+	case LevelNotice:
+		return 10
+	case LevelWarning:
+		return 13
+	case LevelError:
+		return 17
+	case LevelCritical:
+		return 21
+	case LevelAlert:
+		return 23
+	case LevelEmergency:
+		return 24
+	}
+	return 0
+}
+
+// SeverityText returns the OpenTelemetry short name of the level's SeverityNumber.
+func (l Level) SeverityText() string {
+	switch l {
+	case LevelTrace:
+		return "TRACE"
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelNotice:
+		return "INFO2"
+	case LevelWarning:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	case LevelCritical:
+		return "FATAL"
+	case LevelAlert:
+		return "FATAL3"
+	case LevelEmergency:
+		return "FATAL4"
+	}
+	return ""
+}
+
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "critical":
+		return LevelCritical, nil
+	case "error":
+		return LevelError, nil
+	case "warning":
+		return LevelWarning, nil
+	case "info":
+		return LevelInfo, nil
+	case "debug":
+		return LevelDebug, nil
+	case "trace":
+		return LevelTrace, nil
+	case "notice":
+		return LevelNotice, nil
+	case "alert":
+		return LevelAlert, nil
+	case "emergency":
+		return LevelEmergency, nil
+	case "unknown":
+		return LevelUnknown, nil
+	}
+	return LevelUnknown, fmt.Errorf("unknown level: %q", s)
+}
+
 var (
 	glogLevelsMapping = map[byte]Level{
 		'I': LevelInfo,
@@ -42,13 +146,30 @@ var (
 		'E': LevelError,
 		'F': LevelCritical,
 	}
+	// these tokens are common words, so they are considered levels only when written in the upper case
+	capitalizedLevels = map[string]Level{
+		// java.util.logging
+		"SEVERE": LevelError,
+		"CONFIG": LevelDebug,
+		"FINE":   LevelDebug,
+		"FINER":  LevelTrace,
+		"FINEST": LevelTrace,
+		// postgresql
+		"LOG":    LevelInfo,
+		"DETAIL": LevelInfo,
+		"HINT":   LevelInfo,
+		"PANIC":  LevelCritical,
+		// log4j, serilog, etc.
+		"TRACE":   LevelTrace,
+		"VERBOSE": LevelTrace,
+	}
 	priority2Levels = map[string]Level{
-		"0": LevelCritical,
-		"1": LevelCritical,
+		"0": LevelEmergency,
+		"1": LevelAlert,
 		"2": LevelCritical,
 		"3": LevelError,
 		"4": LevelWarning,
-		"5": LevelInfo,
+		"5": LevelNotice,
 		"6": LevelInfo,
 		"7": LevelDebug,
 	}
@@ -61,13 +182,33 @@ func LevelByPriority(priority string) Level {
 	return LevelUnknown
 }
 
+// LevelGuess describes how the level of a line was found.
+type LevelGuess struct {
+	Level Level
+	// Rule is the name of the heuristic that found the level: glog, kmsg, numeric, token, capitalized, redis,
+	// or the name of a user-defined rule.
+	Rule string
+	// Offset and Length locate the level token in the line; Offset is -1 if the level wasn't found.
+	Offset     int
+	Length     int
+	Confidence float64
+}
+
+var unknownLevelGuess = LevelGuess{Level: LevelUnknown, Offset: -1}
+
 func GuessLevel(line string) Level {
+	return GuessLevelDetailed(line).Level
+}
+
+// GuessLevelDetailed works like GuessLevel but also reports which heuristic fired, where the level token is,
+// and how confident the guess is: explicit level fields score higher than bare words.
+func GuessLevelDetailed(line string) LevelGuess {
 	if len(line) > maxLineLenForGuessingLevel {
 		line = line[:maxLineLenForGuessingLevel]
 	}
-	fields := strings.Fields(line)
+	fields, offsets := splitFields(line)
 	if len(fields) == 0 {
-		return LevelUnknown
+		return unknownLevelGuess
 	}
 	limit := len(fields)
 	if limit > guessLevelInFields {
@@ -75,59 +216,267 @@ func GuessLevel(line string) Level {
 	}
 
 	if l := tryGlog(fields); l != LevelUnknown {
-		return l
-	}
-
-	for _, f := range fields[:limit] {
-		subfields := strings.FieldsFunc(f, func(r rune) bool {
-			return r == ']' || r == ')' || r == ';' || r == '|' || r == ':' || r == ',' || r == '.'
-		})
-		for _, sf := range subfields {
-			sf = strings.TrimLeft(strings.ToLower(sf), "\"[(<'")
-			sf = strings.TrimPrefix(sf, "level=")
-
-			if l := len(sf); l == 3 {
-				switch sf {
-				case "dbg", "trc":
-					return LevelDebug
-				case "inf":
-					return LevelInfo
-				case "wrn":
-					return LevelWarning
-				case "err":
-					return LevelError
-				case "ftl":
-					return LevelCritical
+		return LevelGuess{Level: l, Rule: "glog", Offset: offsets[0], Length: 1, Confidence: 0.9}
+	}
+
+	// kernel: <3>[12345.678901] nvme0: I/O timeout
+	if m := kmsgMonotonicRe.FindStringSubmatch(line); m != nil && m[1] != "" {
+		return LevelGuess{Level: kmsgLevel(m[1]), Rule: "kmsg", Offset: 0, Length: len(m[1]) + 2, Confidence: 0.9}
+	}
+
+	// bare capitalized words (e.g., `LOG rotation failed with error`) are considered only if there is no level token
+	for _, bareCapitalized := range []bool{false, true} {
+		if g := guessFieldsLevel(fields[:limit], offsets, bareCapitalized); g.Level != LevelUnknown {
+			return g
+		}
+	}
+	if g := guessNumericLevel(line); g.Level != LevelUnknown {
+		return g
+	}
+	if l, i := guessRedisLevel(fields); l != LevelUnknown {
+		return LevelGuess{Level: l, Rule: "redis", Offset: offsets[i], Length: 1, Confidence: 0.7}
+	}
+	return unknownLevelGuess
+}
+
+func guessFieldsLevel(fields []string, offsets []int, bareCapitalized bool) LevelGuess {
+	for i, f := range fields {
+		start := 0
+		for j := 0; j <= len(f); j++ {
+			var sep byte
+			if j < len(f) {
+				switch sep = f[j]; sep {
+				case ']', ')', ';', '|', ':', ',', '.':
+				default:
+					continue
+				}
+			}
+			if j > start {
+				if g := guessTokenLevel(f[start:j], bareCapitalized || sep == ':'); g.Level != LevelUnknown {
+					g.Offset += offsets[i] + start
+					return g
 				}
-			} else if l >= 4 {
-				switch sf[:4] {
-				case "debu":
-					return LevelDebug
-				case "info", "noti":
-					return LevelInfo
-				case "warn":
-					return LevelWarning
-				case "erro":
-					return LevelError
-				case "crit":
+			}
+			start = j + 1
+		}
+	}
+	return unknownLevelGuess
+}
+
+// guessTokenLevel finds the level in a token; the words of capitalizedLevels (CONFIG, LOG, etc.) are accepted
+// in brackets or quotes, if followed by a colon, or anywhere if anyCapitalized is set.
+func guessTokenLevel(sf string, anyCapitalized bool) LevelGuess {
+	if trimmed := strings.Trim(sf, "\"[(<'>"); trimmed != "" {
+		if l, ok := capitalizedLevels[trimmed]; ok && (anyCapitalized || trimmed != sf) {
+			return LevelGuess{Level: l, Rule: "capitalized", Offset: strings.Index(sf, trimmed), Length: len(trimmed), Confidence: 0.8}
+		}
+	}
+	offset := len(sf) - len(strings.TrimLeft(sf, "\"[(<'"))
+	confidence := 0.7
+	if offset > 0 {
+		confidence = 0.9
+	}
+	token := strings.ToLower(sf[offset:])
+	if strings.HasPrefix(token, "level=") {
+		token = token[len("level="):]
+		offset += len("level=")
+		confidence = 1
+	}
+	l := levelByToken(token, offset > 0)
+	if l == LevelUnknown {
+		return unknownLevelGuess
+	}
+	return LevelGuess{Level: l, Rule: "token", Offset: offset, Length: len(strings.TrimRight(sf[offset:], "\"'>")), Confidence: confidence}
+}
+
+// levelByToken maps a lowercased token to a level; trace, verbose and panic are common words,
+// so they are considered levels only if explicit (in brackets, quotes or after `level=`).
+func levelByToken(sf string, explicit bool) Level {
+	if explicit {
+		switch strings.TrimRight(sf, "\"'>") {
+		case "trace", "verbose":
+			return LevelTrace
+		case "panic":
+			return LevelCritical
+		}
+	}
+
+	if l := len(sf); l == 3 {
+		switch sf {
+		case "dbg":
+			return LevelDebug
+		case "trc", "vrb":
+			return LevelTrace
+		case "inf":
+			return LevelInfo
+		case "wrn":
+			return LevelWarning
+		case "err":
+			return LevelError
+		case "ftl":
+			return LevelCritical
+		}
+	} else if l >= 4 {
+		switch sf[:4] {
+		case "debu":
+			return LevelDebug
+		case "info":
+			return LevelInfo
+		case "noti":
+			return LevelNotice
+		case "warn":
+			return LevelWarning
+		case "erro":
+			return LevelError
+		case "crit":
+			return LevelCritical
+		case "emer", "fata", "aler":
+			if l >= 5 {
+				switch sf[:5] {
+				case "emerg":
+					return LevelEmergency
+				case "alert":
+					return LevelAlert
+				case "fatal":
 					return LevelCritical
-				case "emer", "fata", "aler":
-					if l >= 5 {
-						switch sf[:5] {
-						case "emerg", "fatal", "alert":
-							return LevelCritical
-						}
-					}
 				}
 			}
 		}
 	}
-	if l := guessRedisLevel(fields); l != LevelUnknown {
-		return l
+	return LevelUnknown
+}
+
+// splitFields works like strings.Fields but also returns the offsets of the fields
+func splitFields(s string) ([]string, []int) {
+	var fields []string
+	var offsets []int
+	start := -1
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				fields = append(fields, s[start:i])
+				offsets = append(offsets, start)
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, s[start:])
+		offsets = append(offsets, start)
+	}
+	return fields, offsets
+}
+
+var (
+	numericLevelRe = regexp.MustCompile(`(?i)(?:"(level|severity|severity_?number|syslog_?severity)"\s*:\s*"?|(?:^|\s)(level|severity|severity_?number|syslog_?severity)=)(\d{1,2})\b`)
+)
+
+// guessNumericLevel handles numeric levels in JSON and logfmt lines, the scale is chosen by the key and the format:
+//
+//	{"level":30,"time":1531171074631,"msg":"hello world"}  - bunyan & pino (JSON level): 10 trace, 20 debug, 30 info, 40 warn, 50 error, 60 fatal
+//	{"severityNumber":17,"body":"connection failed"}       - OpenTelemetry SeverityNumber: 1-24
+//	ts=2026-10-14T10:00:00Z level=3 msg="disk failure"     - syslog severity (logfmt level, severity): 0-7
+func guessNumericLevel(line string) LevelGuess {
+	if !strings.HasPrefix(strings.TrimSpace(line), "{") && !strings.Contains(line, "=") {
+		return unknownLevelGuess
+	}
+	m := numericLevelRe.FindStringSubmatchIndex(line)
+	if m == nil {
+		return unknownLevelGuess
+	}
+	isJson := m[2] >= 0
+	name := strings.ToLower(line[max(m[2], m[4]):max(m[3], m[5])])
+	value := line[m[6]:m[7]]
+	n := atoi(value)
+	l := LevelUnknown
+	switch {
+	case strings.HasSuffix(name, "number"):
+		l = LevelBySeverityNumber(n)
+	case name == "level" && isJson:
+		l = levelByBunyanLevel(n)
+	default:
+		l = LevelByPriority(value)
+	}
+	if l == LevelUnknown {
+		return unknownLevelGuess
+	}
+	return LevelGuess{Level: l, Rule: "numeric", Offset: m[6], Length: len(value), Confidence: 0.9}
+}
+
+// LevelBySeverityNumber maps OpenTelemetry SeverityNumber (1-24) to Level.
+func LevelBySeverityNumber(n int) Level {
+	switch {
+	case n > 24:
+		return LevelUnknown
+	case n == 24:
+		return LevelEmergency
+	case n == 23:
+		return LevelAlert
+	case n >= 21:
+		return LevelCritical
+	case n >= 17:
+		return LevelError
+	case n >= 13:
+		return LevelWarning
+	case n >= 10:
+		return LevelNotice
+	case n == 9:
+		return LevelInfo
+	case n >= 5:
+		return LevelDebug
+	case n >= 1:
+		return LevelTrace
 	}
 	return LevelUnknown
 }
 
+func levelByBunyanLevel(n int) Level {
+	switch {
+	case n < 10:
+		return LevelUnknown
+	case n >= 60:
+		return LevelCritical
+	case n >= 50:
+		return LevelError
+	case n >= 40:
+		return LevelWarning
+	case n >= 30:
+		return LevelInfo
+	case n >= 20:
+		return LevelDebug
+	}
+	return LevelTrace
+}
+
+var (
+	exceptionLineRe = regexp.MustCompile(`^(?:[A-Za-z_$][\w$]*\.)*[A-Za-z_$]*(?:Exception|Error)(?::\s|$)`)
+)
+
+// guessMessageLevel looks deeper into a message when its first line doesn't contain a level:
+// panics are considered critical, stack traces and exception lines are considered errors.
+func guessMessageLevel(content string, e *Exception) Level {
+	level := LevelUnknown
+	if e != nil {
+		if e.Language == LanguageGo {
+			return LevelCritical
+		}
+		level = LevelError
+	}
+	for _, l := range strings.Split(content, "\n") {
+		switch {
+		case strings.HasPrefix(l, "panic: ") || strings.HasPrefix(l, "fatal error: ") || isRustPanic(l):
+			return LevelCritical
+		case strings.HasPrefix(l, "Exception in thread ") || strings.HasPrefix(l, "Traceback (most recent call last):"):
+			level = LevelError
+		case exceptionLineRe.MatchString(l):
+			level = LevelError
+		}
+	}
+	return level
+}
+
 func tryGlog(fields []string) Level {
 	firstField := fields[0]
 	if len(firstField) != 5 {
@@ -155,21 +504,21 @@ func tryGlog(fields []string) Level {
 
 // redis 5.x: the year was added
 // 1:S 12 Nov 2019 07:52:11.999 * FAIL message received from X about Y
-func guessRedisLevel(fields []string) Level {
+func guessRedisLevel(fields []string) (Level, int) {
 	if len(fields) < 6 {
-		return LevelUnknown
+		return LevelUnknown, -1
 	}
 	if strings.HasPrefix(fields[0], "[") && strings.HasSuffix(fields[0], "]") {
-		return redisCharToLevel(fields[4])
+		return redisCharToLevel(fields[4]), 4
 	}
 	if len(strings.Split(fields[0], ":")) == 2 {
 		if len(fields[3]) == 4 { //redis 5.x+
-			return redisCharToLevel(fields[5])
+			return redisCharToLevel(fields[5]), 5
 		} else {
-			return redisCharToLevel(fields[4])
+			return redisCharToLevel(fields[4]), 4
 		}
 	}
-	return LevelUnknown
+	return LevelUnknown, -1
 }
 
 func redisCharToLevel(level string) Level {
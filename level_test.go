@@ -2,6 +2,7 @@ package logparser
 
 import (
 	"github.com/stretchr/testify/assert"
+	"strconv"
 	"testing"
 )
 
@@ -15,6 +16,13 @@ func TestGuessLevelGlog(t *testing.T) {
 	assert.Equal(t, LevelCritical, GuessLevel(`F0825 185142 test.cc:22] Check failed: write(1, NULL, 2) >= 0 Write NULL failed: Bad address [14]`))
 }
 
+func TestGuessLevelKmsg(t *testing.T) {
+	assert.Equal(t, LevelError, GuessLevel(`<3>[12345.678901] nvme0: I/O timeout`))
+	assert.Equal(t, LevelInfo, GuessLevel(`<6>[    0.000000] Linux version 6.1.0`))
+	assert.Equal(t, LevelWarning, GuessLevel(`<12>[   12.000001] systemd[1]: starting`))
+	assert.Equal(t, LevelUnknown, GuessLevel(`[12345.678901] nvme0: I/O timeout`))
+}
+
 func TestGuessLevelRedis(t *testing.T) {
 	assert.Equal(t, LevelWarning, GuessLevel(`[4018] 14 Nov 07:01:22.119 * Background saving terminated with success`))
 	assert.Equal(t, LevelInfo, GuessLevel(`1:S 12 Nov 07:52:11.999 - some msg`))
@@ -36,8 +44,154 @@ func TestGuessLevel(t *testing.T) {
 	assert.Equal(t, LevelError, GuessLevel("[06:23:18 ERR] message"))
 	assert.Equal(t, LevelCritical, GuessLevel("[06:23:18 FTL] message"))
 
-	assert.Equal(t, LevelCritical, GuessLevel(`2024/02/29 11:01:03 [emerg] 1#1: duplicate location "/loc-path" in /etc/nginx/conf.d/default.conf:33`))
-	assert.Equal(t, LevelCritical, GuessLevel(`nginx: [alert] could not open error log file: open() "/var/log/nginx/error.log" failed (13: Permission denied)`))
+	assert.Equal(t, LevelEmergency, GuessLevel(`2024/02/29 11:01:03 [emerg] 1#1: duplicate location "/loc-path" in /etc/nginx/conf.d/default.conf:33`))
+	assert.Equal(t, LevelAlert, GuessLevel(`nginx: [alert] could not open error log file: open() "/var/log/nginx/error.log" failed (13: Permission denied)`))
 	assert.Equal(t, LevelCritical, GuessLevel(`2022/05/14 07:08:37 [crit] 6689#6689: *16721837 SSL_do_handshake() failed (SSL: error:1420918C:SSL routines:tls_early_post_process_client_hello:version too low) while SSL handshaking`))
 	assert.Equal(t, LevelError, GuessLevel(`2009/01/01 19:45:44 [error]  29874#0: *98 open() "/var/www/one/nonexistent.html" failed (2: No such file or directory), client: 11.22.33.44, server: one.org, request: "GET /nonexistent.html HTTP/1.1", host: "one.org"`))
 }
+
+func TestGuessMessageLevel(t *testing.T) {
+	level := func(content string) Level {
+		return guessMessageLevel(content, DetectException(content))
+	}
+	assert.Equal(t, LevelError, level("Exception in thread \"main\" java.lang.NullPointerException\n\tat com.example.MyClass.methodA(MyClass.java:10)"))
+	assert.Equal(t, LevelError, level("Traceback (most recent call last):\n  File \"/app/main.py\", line 10, in <module>\n    func()\nConnectionError"))
+	assert.Equal(t, LevelError, level("Order processing stopped\njava.lang.IllegalStateException: queue is closed"))
+	assert.Equal(t, LevelError, level("TypeError: Cannot read properties of undefined (reading 'id')"))
+	assert.Equal(t, LevelCritical, level("panic: something went wrong\n\ngoroutine 1 [running]:\nmain.main()\n\t/app/main.go:9 +0x22"))
+	assert.Equal(t, LevelCritical, level("fatal error: concurrent map writes"))
+	assert.Equal(t, LevelCritical, level("thread 'main' panicked at src/main.rs:4:5:\nexplicit panic"))
+
+	assert.Equal(t, LevelUnknown, level("GET /api/errors 200"))
+	assert.Equal(t, LevelUnknown, level("request finished without Error"))
+	assert.Equal(t, LevelUnknown, level("ErrorCount: 0"))
+	assert.Equal(t, LevelUnknown, level("Deployment plan:\n#1 drain the node\n#2 upgrade the kernel"))
+	assert.Equal(t, LevelUnknown, level("Maintenance window:\n  at 10:00 we restart the database"))
+	assert.Equal(t, LevelUnknown, level("Some text\n\tat the office"))
+}
+
+func TestGuessLevelJava(t *testing.T) {
+	// java.util.logging
+	assert.Equal(t, LevelError, GuessLevel(`SEVERE: Servlet.service() for servlet [dispatcher] threw exception`))
+	assert.Equal(t, LevelError, GuessLevel(`14-Oct-2026 10:00:00.000 SEVERE [main] org.apache.catalina.core.StandardContext.startInternal One or more listeners failed to start.`))
+	assert.Equal(t, LevelWarning, GuessLevel(`WARNING: An illegal reflective access operation has occurred`))
+	assert.Equal(t, LevelDebug, GuessLevel(`CONFIG: Using default configuration`))
+	assert.Equal(t, LevelDebug, GuessLevel(`FINE: Loading class com.example.App`))
+	assert.Equal(t, LevelTrace, GuessLevel(`FINER: ENTRY com.example.App run`))
+	assert.Equal(t, LevelTrace, GuessLevel(`FINEST: connection pool state: 3/10`))
+	assert.Equal(t, LevelUnknown, GuessLevel(`Everything is fine, config reloaded`))
+
+	// log4j & logback
+	assert.Equal(t, LevelTrace, GuessLevel(`2026-10-14 10:00:00,000 TRACE [main] com.example.App - entering run()`))
+	assert.Equal(t, LevelTrace, GuessLevel(`10:00:00.000 [main] TRACE com.example.App - entering run()`))
+	assert.Equal(t, LevelTrace, GuessLevel(`{"@timestamp":"2026-10-14T10:00:00.000Z","level":"TRACE","logger_name":"com.example.App"}`))
+	assert.Equal(t, LevelTrace, GuessLevel(`ts=2026-10-14T10:00:00Z level=trace msg="entering run()"`))
+	assert.Equal(t, LevelError, GuessLevel(`Loading CONFIG from /etc/app.yaml failed: error opening file`))
+	assert.Equal(t, LevelError, GuessLevel(`2026-10-14 10:00:00 LOG rotation failed with error`))
+	assert.Equal(t, LevelUnknown, GuessLevel(`Sending trace to collector`))
+	assert.Equal(t, LevelUnknown, GuessLevel(`verbose mode enabled`))
+	assert.Equal(t, LevelUnknown, GuessLevel(`recovered from panic in handler`))
+}
+
+func TestGuessLevelPostgres(t *testing.T) {
+	assert.Equal(t, LevelInfo, GuessLevel(`2026-10-14 10:00:00.123 UTC [1] LOG:  database system is ready to accept connections`))
+	assert.Equal(t, LevelError, GuessLevel(`2026-10-14 10:00:00.123 UTC [42] ERROR:  duplicate key value violates unique constraint "users_pkey"`))
+	assert.Equal(t, LevelInfo, GuessLevel(`2026-10-14 10:00:00.123 UTC [42] DETAIL:  Key (id)=(1) already exists.`))
+	assert.Equal(t, LevelInfo, GuessLevel(`2026-10-14 10:00:00.123 UTC [42] HINT:  Consider increasing the configuration parameter "max_wal_size".`))
+	assert.Equal(t, LevelCritical, GuessLevel(`2026-10-14 10:00:00.123 UTC [42] FATAL:  password authentication failed for user "app"`))
+	assert.Equal(t, LevelCritical, GuessLevel(`2026-10-14 10:00:00.123 UTC [42] PANIC:  could not write to file "pg_wal/xlogtemp.42": No space left on device`))
+	assert.Equal(t, LevelUnknown, GuessLevel(`rotating the log with a hint`))
+}
+
+func TestGuessLevelDotNet(t *testing.T) {
+	assert.Equal(t, LevelTrace, GuessLevel(`[10:00:00 VRB] Request starting`))
+	assert.Equal(t, LevelTrace, GuessLevel(`2026-10-14 10:00:00.000 +00:00 [Verbose] Request starting`))
+	assert.Equal(t, LevelInfo, GuessLevel(`2026-10-14 10:00:00.0000|Information|Microsoft.Hosting.Lifetime|Application started`))
+	assert.Equal(t, LevelInfo, GuessLevel(`info: Microsoft.Hosting.Lifetime[0]`))
+}
+
+func TestGuessLevelNumeric(t *testing.T) {
+	// pino & bunyan
+	assert.Equal(t, LevelTrace, GuessLevel(`{"level":10,"time":1531171074631,"msg":"entering handler","pid":657,"hostname":"node-1"}`))
+	assert.Equal(t, LevelDebug, GuessLevel(`{"level":20,"time":1531171074631,"msg":"cache miss","pid":657,"hostname":"node-1"}`))
+	assert.Equal(t, LevelInfo, GuessLevel(`{"level":30,"time":1531171074631,"msg":"request completed","pid":657,"hostname":"node-1"}`))
+	assert.Equal(t, LevelWarning, GuessLevel(`{"level":40,"time":1531171074631,"msg":"slow request","pid":657,"hostname":"node-1"}`))
+	assert.Equal(t, LevelError, GuessLevel(`{"name":"myapp","hostname":"node-1","pid":1,"level":50,"msg":"request failed","time":"2026-10-14T10:00:00.000Z","v":0}`))
+	assert.Equal(t, LevelCritical, GuessLevel(`{"name":"myapp","hostname":"node-1","pid":1,"level":60,"msg":"uncaught exception","time":"2026-10-14T10:00:00.000Z","v":0}`))
+
+	// OpenTelemetry
+	assert.Equal(t, LevelTrace, GuessLevel(`{"severityNumber":1,"body":"span started"}`))
+	assert.Equal(t, LevelInfo, GuessLevel(`{"severityNumber":9,"body":"user logged in"}`))
+	assert.Equal(t, LevelWarning, GuessLevel(`{"SeverityNumber":13,"Body":"retrying"}`))
+	assert.Equal(t, LevelError, GuessLevel(`{"severity_number":"17","body":"export failed"}`))
+	assert.Equal(t, LevelCritical, GuessLevel(`{"severityNumber":21,"body":"shutting down"}`))
+
+	// syslog severity
+	assert.Equal(t, LevelError, GuessLevel(`{"severity":"3","message":"disk failure"}`))
+	assert.Equal(t, LevelWarning, GuessLevel(`ts=2026-10-14T10:00:00Z level=4 msg="disk is almost full"`))
+	assert.Equal(t, LevelUnknown, GuessLevel(`ts=2026-10-14T10:00:00Z level=30 msg="started"`))
+	assert.Equal(t, LevelUnknown, GuessLevel(`{"level":5,"msg":"started"}`))
+
+	// textual levels win, other numeric fields are ignored
+	assert.Equal(t, LevelError, GuessLevel(`level=error msg="job failed" priority=5`))
+	assert.Equal(t, LevelUnknown, GuessLevel(`scheduling job id=5 priority=1`))
+	assert.Equal(t, LevelUnknown, GuessLevel(`{"msg":"enqueued","priority":2}`))
+
+	assert.Equal(t, LevelUnknown, GuessLevel(`{"count":30,"msg":"processed"}`))
+	assert.Equal(t, LevelUnknown, GuessLevel(`loglevel=3 retries=30`))
+}
+
+func TestLevelByPriority(t *testing.T) {
+	levels := []Level{LevelEmergency, LevelAlert, LevelCritical, LevelError, LevelWarning, LevelNotice, LevelInfo, LevelDebug}
+	for i, l := range levels {
+		assert.Equal(t, l, LevelByPriority(strconv.Itoa(i)))
+	}
+	assert.Equal(t, LevelUnknown, LevelByPriority("8"))
+}
+
+func TestLevelCoarse(t *testing.T) {
+	assert.Equal(t, LevelDebug, LevelTrace.Coarse())
+	assert.Equal(t, LevelInfo, LevelNotice.Coarse())
+	assert.Equal(t, LevelCritical, LevelAlert.Coarse())
+	assert.Equal(t, LevelCritical, LevelEmergency.Coarse())
+	assert.Equal(t, LevelError, LevelError.Coarse())
+	assert.Equal(t, LevelUnknown, LevelUnknown.Coarse())
+}
+
+func TestLevelSeverityNumber(t *testing.T) {
+	for _, l := range []Level{LevelTrace, LevelDebug, LevelInfo, LevelNotice, LevelWarning, LevelError, LevelCritical, LevelAlert, LevelEmergency} {
+		assert.Equal(t, l, LevelBySeverityNumber(l.SeverityNumber()), l.String())
+		assert.NotEmpty(t, l.SeverityText())
+	}
+	assert.Equal(t, 0, LevelUnknown.SeverityNumber())
+	assert.Equal(t, "INFO2", LevelNotice.SeverityText())
+	assert.Equal(t, LevelUnknown, LevelBySeverityNumber(25))
+}
+
+func TestGuessLevelDetailed(t *testing.T) {
+	check := func(line string, level Level, rule string, token string, confidence float64) {
+		t.Helper()
+		g := GuessLevelDetailed(line)
+		assert.Equal(t, level, g.Level)
+		assert.Equal(t, rule, g.Rule)
+		if token == "" {
+			assert.Equal(t, -1, g.Offset)
+			return
+		}
+		if assert.True(t, g.Offset >= 0) {
+			assert.Equal(t, token, line[g.Offset:g.Offset+g.Length])
+		}
+		assert.Equal(t, confidence, g.Confidence)
+	}
+	check(`W0430 11:29:23.177635       1 nanny.go:120] Got EOF from stdout`, LevelWarning, "glog", "W", 0.9)
+	check(`2016-02-04T07:53:57.505612354Z" level=error msg="HTTP Error" err="No such image: -f" statusCode=404`, LevelError, "token", "error", 1)
+	check(`[2020-06-25 17:35:37,609][DEBUG][action.search            ] [srv] [tweets-100][6]`, LevelDebug, "token", "DEBUG", 0.9)
+	check(`2024/02/29 11:01:03 [emerg] 1#1: duplicate location "/loc-path"`, LevelEmergency, "token", "emerg", 0.9)
+	check(`2023-10-12 13:58:41 ERROR: something bad happened`, LevelError, "token", "ERROR", 0.7)
+	check(`2026-10-14 10:00:00.123 UTC [1] LOG:  database system is ready to accept connections`, LevelInfo, "capitalized", "LOG", 0.8)
+	check(`{"level":50,"msg":"request failed"}`, LevelError, "numeric", "50", 0.9)
+	check(`1:S 12 Nov 2019 07:52:11.999 . verbosed`, LevelDebug, "redis", ".", 0.7)
+	check(`<4>[   12.345678] ata1.00: failed command: READ FPDMA QUEUED`, LevelWarning, "kmsg", "<4>", 0.9)
+	check(`  €€ level=warn`, LevelWarning, "token", "warn", 1)
+	check(`just a message`, LevelUnknown, "", "", 0)
+}
@@ -0,0 +1,138 @@
+package logparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	LevelRuleStageBefore = "before"
+	LevelRuleStageAfter  = "after"
+)
+
+// LevelRule maps a line matching a regular expression or containing a token to a level.
+// Rules of the "before" stage (default) override the built-in heuristics,
+// rules of the "after" stage apply only if the heuristics haven't found a level.
+type LevelRule struct {
+	Name   string `json:"name"`
+	Regexp string `json:"regexp"`
+	Token  string `json:"token"`
+	Level  string `json:"level"`
+	Stage  string `json:"stage"`
+}
+
+type LevelRules struct {
+	before []levelRule
+	after  []levelRule
+}
+
+type levelRule struct {
+	name  string
+	re    *regexp.Regexp
+	token string
+	level Level
+}
+
+func NewLevelRules(rules ...LevelRule) (*LevelRules, error) {
+	res := &LevelRules{}
+	for i, r := range rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		rule := levelRule{name: name, token: r.Token}
+		var err error
+		if rule.level, err = ParseLevel(r.Level); err != nil {
+			return nil, fmt.Errorf("level rule %s: %w", name, err)
+		}
+		switch {
+		case r.Regexp != "" && r.Token != "":
+			return nil, fmt.Errorf("level rule %s: either regexp or token must be specified, not both", name)
+		case r.Regexp != "":
+			if rule.re, err = regexp.Compile(r.Regexp); err != nil {
+				return nil, fmt.Errorf("level rule %s: invalid regexp: %w", name, err)
+			}
+		case r.Token == "":
+			return nil, fmt.Errorf("level rule %s: regexp or token must be specified", name)
+		}
+		switch r.Stage {
+		case "", LevelRuleStageBefore:
+			res.before = append(res.before, rule)
+		case LevelRuleStageAfter:
+			res.after = append(res.after, rule)
+		default:
+			return nil, fmt.Errorf("level rule %s: unknown stage %q", name, r.Stage)
+		}
+	}
+	return res, nil
+}
+
+// LoadLevelRules reads rules from a JSON file containing a list of LevelRule objects.
+func LoadLevelRules(path string) (*LevelRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []LevelRule
+	if err = json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse level rules %s: %w", path, err)
+	}
+	return NewLevelRules(rules...)
+}
+
+// GuessLevel evaluates the "before" rules, the built-in heuristics and then the "after" rules.
+func (r *LevelRules) GuessLevel(line string) Level {
+	return r.GuessLevelDetailed(line).Level
+}
+
+func (r *LevelRules) GuessLevelDetailed(line string) LevelGuess {
+	if g, ok := r.match(r.before, line); ok {
+		return g
+	}
+	if g := GuessLevelDetailed(line); g.Level != LevelUnknown {
+		return g
+	}
+	g, _ := r.match(r.after, line)
+	return g
+}
+
+func (r *LevelRules) match(rules []levelRule, line string) (LevelGuess, bool) {
+	if len(rules) == 0 {
+		return unknownLevelGuess, false
+	}
+	if len(line) > maxLineLenForGuessingLevel {
+		line = line[:maxLineLenForGuessingLevel]
+	}
+	var fields []string
+	var offsets []int
+	for _, rule := range rules {
+		g := LevelGuess{Level: rule.level, Rule: rule.name, Offset: -1, Confidence: 1}
+		if rule.re != nil {
+			if loc := rule.re.FindStringIndex(line); loc != nil {
+				if rule.level != LevelUnknown {
+					g.Offset, g.Length = loc[0], loc[1]-loc[0]
+				}
+				return g, true
+			}
+			continue
+		}
+		if fields == nil {
+			fields, offsets = splitFields(line)
+			if len(fields) > guessLevelInFields {
+				fields = fields[:guessLevelInFields]
+			}
+		}
+		for i, f := range fields {
+			if f == rule.token || strings.Trim(f, "[]()<>:;,|\"'") == rule.token {
+				if rule.level != LevelUnknown {
+					g.Offset, g.Length = offsets[i]+strings.Index(f, rule.token), len(rule.token)
+				}
+				return g, true
+			}
+		}
+	}
+	return unknownLevelGuess, false
+}
@@ -0,0 +1,67 @@
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelRules(t *testing.T) {
+	r, err := NewLevelRules(
+		LevelRule{Token: "SEVERE", Level: "critical"},
+		LevelRule{Name: "column", Regexp: `^\d+ E `, Level: "unknown"},
+		LevelRule{Regexp: `^AUDIT\b`, Level: "info", Stage: LevelRuleStageAfter},
+		LevelRule{Regexp: `^AUDIT denied`, Level: "warning", Stage: LevelRuleStageAfter},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, LevelCritical, r.GuessLevel(`2026-10-14 10:00:00 SEVERE: startup failed`))
+	assert.Equal(t, LevelCritical, r.GuessLevel(`[SEVERE] startup failed`))
+	assert.Equal(t, LevelUnknown, r.GuessLevel(`12 E error_rate=0.1`))
+	assert.Equal(t, LevelInfo, r.GuessLevel(`AUDIT user=admin action=login`))
+	assert.Equal(t, LevelError, r.GuessLevel(`AUDIT error writing the audit log`))
+	assert.Equal(t, LevelWarning, r.GuessLevel(`WARN disk is almost full`))
+}
+
+func TestLevelRulesErrors(t *testing.T) {
+	_, err := NewLevelRules(LevelRule{Token: "X", Level: "severe"})
+	assert.EqualError(t, err, `level rule #0: unknown level: "severe"`)
+	_, err = NewLevelRules(LevelRule{Name: "re", Regexp: "(", Level: "error"})
+	assert.ErrorContains(t, err, "level rule re: invalid regexp")
+	_, err = NewLevelRules(LevelRule{Level: "error"})
+	assert.EqualError(t, err, "level rule #0: regexp or token must be specified")
+	_, err = NewLevelRules(LevelRule{Token: "X", Level: "error", Stage: "middle"})
+	assert.EqualError(t, err, `level rule #0: unknown stage "middle"`)
+}
+
+func TestLoadLevelRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"name": "notice", "token": "NOTICE+", "level": "warning"}]`), 0644))
+	r, err := LoadLevelRules(path)
+	require.NoError(t, err)
+	assert.Equal(t, LevelWarning, r.GuessLevel(`2026-10-14 10:00:00 NOTICE+ quota is almost exceeded`))
+}
+
+func TestLevelRulesDetailed(t *testing.T) {
+	r, err := NewLevelRules(
+		LevelRule{Name: "severe", Token: "SEVERE", Level: "critical"},
+		LevelRule{Name: "audit", Regexp: `AUDIT`, Level: "info", Stage: LevelRuleStageAfter},
+	)
+	require.NoError(t, err)
+
+	line := `2026-10-14 10:00:00 [SEVERE] startup failed`
+	g := r.GuessLevelDetailed(line)
+	assert.Equal(t, "severe", g.Rule)
+	assert.Equal(t, "SEVERE", line[g.Offset:g.Offset+g.Length])
+
+	line = `2026-10-14 10:00:00 user=admin AUDIT login`
+	g = r.GuessLevelDetailed(line)
+	assert.Equal(t, LevelInfo, g.Level)
+	assert.Equal(t, "audit", g.Rule)
+	assert.Equal(t, "AUDIT", line[g.Offset:g.Offset+g.Length])
+
+	assert.Equal(t, "token", r.GuessLevelDetailed(`2026-10-14 10:00:00 WARN AUDIT log is full`).Rule)
+}
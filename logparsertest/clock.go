@@ -0,0 +1,83 @@
+// Package logparsertest provides helpers for testing code built on top of logparser.
+package logparsertest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coroot/logparser"
+)
+
+// FakeClock is a logparser.Clock that only moves forward when Advance is called.
+type FakeClock struct {
+	lock    sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) logparser.Ticker {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	t := &fakeTicker{clock: c, period: d, next: c.now.Add(d), ch: make(chan time.Time, 1)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance moves the clock forward by d and fires the tickers that are due.
+// Like time.Ticker, a ticker drops ticks if its channel hasn't been read.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.tickers {
+		if t.next.After(c.now) {
+			continue
+		}
+		for !t.next.After(c.now) {
+			t.next = t.next.Add(t.period)
+		}
+		select {
+		case t.ch <- c.now:
+		default:
+		}
+	}
+}
+
+// Tickers returns the number of active tickers, which lets tests wait until a goroutine has started ticking.
+func (c *FakeClock) Tickers() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.tickers)
+}
+
+type fakeTicker struct {
+	clock  *FakeClock
+	period time.Duration
+	next   time.Time
+	ch     chan time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+	for i, tt := range t.clock.tickers {
+		if tt == t {
+			t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package logparsertest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coroot/logparser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClockMultilineCollector(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := NewFakeClock(time.Unix(100, 0))
+	m := logparser.NewMultilineCollector(ctx, time.Second, 1024, logparser.WithClock(clock))
+	require.Eventually(t, func() bool { return clock.Tickers() == 1 }, time.Second, time.Millisecond)
+
+	m.Add(logparser.LogEntry{Timestamp: clock.Now(), Content: "foo"})
+	m.Add(logparser.LogEntry{Timestamp: clock.Now(), Content: "  bar"})
+
+	clock.Advance(time.Second)
+	select {
+	case msg := <-m.Messages:
+		t.Fatalf("unexpected message: %s", msg.Content)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case msg := <-m.Messages:
+		assert.Equal(t, "foo\n  bar", msg.Content)
+		assert.Equal(t, time.Unix(100, 0), msg.Timestamp)
+	case <-time.After(time.Second):
+		t.Fatal("message hasn't been flushed")
+	}
+}
+
+func TestFakeClockParser(t *testing.T) {
+	clock := NewFakeClock(time.Unix(100, 0))
+	ch := make(chan logparser.LogEntry)
+	var ts []time.Time
+	p := logparser.NewParser(ch, nil, func(t time.Time, level logparser.Level, patternHash string, msg string) {
+		ts = append(ts, t)
+	}, time.Second, 10, logparser.WithParserClock(clock))
+	defer p.Stop()
+
+	ch <- logparser.LogEntry{Content: "ERROR foo"}
+	clock.Advance(time.Minute)
+	ch <- logparser.LogEntry{Content: "ERROR bar"}
+	p.Flush()
+
+	var messages int
+	for _, c := range p.GetCounters() {
+		messages += c.Messages
+	}
+	assert.Equal(t, 2, messages)
+	assert.Equal(t, []time.Time{time.Unix(100, 0), time.Unix(160, 0)}, ts)
+}
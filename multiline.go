@@ -2,9 +2,11 @@ package logparser
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -12,10 +14,24 @@ var (
 	multilineCollectorLimit = 64 * 1024
 )
 
+var (
+	ErrInvalidUTF8 = errors.New("invalid UTF-8")
+	ErrTruncated   = errors.New("message exceeds the size limit and has been truncated")
+)
+
 type Message struct {
 	Timestamp time.Time
 	Content   string
 	Level     Level
+	// LevelInferred is set when the level was derived from the message content by heuristics rather than an explicit level token.
+	LevelInferred bool
+	// LevelGuess describes how the level was found in the first line of the message.
+	LevelGuess LevelGuess
+	Exception  *Exception
+	// RawContent is the content before preprocessing (e.g., with ANSI escape sequences), set if WithRawContent is used.
+	RawContent string
+	// Labels are the labels of the first entry of the message (e.g., the syslog hostname and app-name).
+	Labels map[string]string
 }
 
 type MultilineCollector struct {
@@ -24,41 +40,134 @@ type MultilineCollector struct {
 	timeout time.Duration
 	limit   int
 
-	ts    time.Time
-	level Level
-	lines []string
-	size  int
+	ts         time.Time
+	level      Level
+	levelGuess LevelGuess
+	labels     map[string]string
+	lines      []string
+	size       int
+	rawLines   []string
+	rawSize    int
 
 	lock            sync.Mutex
 	closed          bool
 	lastReceiveTime time.Time
 
+	eventTime  bool
+	clock      Clock
+	levelRules *LevelRules
+	keepANSI   bool
+	keepRaw    bool
+
+	contentTimestamps bool
+	timestampResolver *TimestampResolver
+
+	errorCallbacks []func(entry LogEntry, err error)
+	truncated      bool
+
 	isFirstLineContainsTimestamp bool
 	pythonTraceback              bool
 	pythonTracebackExpected      bool
+	rustPanic                    bool
+	rustPanicMessageExpected     bool
+	elixirException              bool
+}
+
+type MultilineCollectorOption func(*MultilineCollector)
+
+// WithEventTime makes the collector decide when a message is complete by the gaps between
+// entry timestamps rather than by the wall clock. This keeps the grouping of replayed
+// (archived) logs independent of the processing speed. The last message is emitted by
+// Watermark or Flush.
+func WithEventTime() MultilineCollectorOption {
+	return func(m *MultilineCollector) {
+		m.eventTime = true
+	}
+}
+
+func WithClock(c Clock) MultilineCollectorOption {
+	return func(m *MultilineCollector) {
+		m.clock = c
+	}
+}
+
+func WithLevelRules(r *LevelRules) MultilineCollectorOption {
+	return func(m *MultilineCollector) {
+		m.levelRules = r
+	}
+}
+
+// WithoutANSIStripping disables removing ANSI escape sequences (colors) before detecting levels, timestamps and patterns.
+func WithoutANSIStripping() MultilineCollectorOption {
+	return func(m *MultilineCollector) {
+		m.keepANSI = true
+	}
+}
+
+// WithRawContent makes the collector keep the original content of messages in Message.RawContent.
+func WithRawContent() MultilineCollectorOption {
+	return func(m *MultilineCollector) {
+		m.keepRaw = true
+	}
+}
+
+// WithContentTimestamps makes the collector use the timestamp found in the first line of a message
+// as the message timestamp instead of the entry timestamp.
+func WithContentTimestamps() MultilineCollectorOption {
+	return func(m *MultilineCollector) {
+		m.contentTimestamps = true
+	}
+}
+
+// WithTimestampLocation sets the time zone of the extracted timestamps that don't specify one.
+func WithTimestampLocation(loc *time.Location) MultilineCollectorOption {
+	return func(m *MultilineCollector) {
+		m.timestampResolver = &TimestampResolver{Location: loc}
+	}
+}
+
+// WithErrorCallback adds a function called for the dropped (ErrInvalidUTF8) and truncated (ErrTruncated) entries.
+// It's called synchronously and must not call the collector.
+func WithErrorCallback(cb func(entry LogEntry, err error)) MultilineCollectorOption {
+	return func(m *MultilineCollector) {
+		m.errorCallbacks = append(m.errorCallbacks, cb)
+	}
 }
 
-func NewMultilineCollector(ctx context.Context, timeout time.Duration, limit int) *MultilineCollector {
+func NewMultilineCollector(ctx context.Context, timeout time.Duration, limit int, opts ...MultilineCollectorOption) *MultilineCollector {
 	m := &MultilineCollector{
 		timeout:  timeout,
 		limit:    limit,
 		Messages: make(chan Message, 1),
+		clock:    realClock{},
+
+		timestampResolver: defaultTimestampResolver,
+	}
+	for _, opt := range opts {
+		opt(m)
 	}
 	go m.dispatch(ctx)
 	return m
 }
 
 func (m *MultilineCollector) dispatch(ctx context.Context) {
-	ticker := time.NewTicker(m.timeout)
-	defer ticker.Stop()
 	defer close(m.Messages)
 
+	if m.eventTime {
+		<-ctx.Done()
+		m.closed = true
+		return
+	}
+
+	ticker := m.clock.NewTicker(m.timeout)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			m.closed = true
 			return
-		case t := <-ticker.C:
+		case t := <-ticker.C():
 			m.lock.Lock()
 			if t.Sub(m.lastReceiveTime) > m.timeout {
 				m.flushMessage()
@@ -69,17 +178,26 @@ func (m *MultilineCollector) dispatch(ctx context.Context) {
 }
 
 func (m *MultilineCollector) Add(entry LogEntry) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	if !utf8.ValidString(entry.Content) {
+		m.reportError(entry, ErrInvalidUTF8)
 		return
 	}
 
-	m.lock.Lock()
-	defer m.lock.Unlock()
+	if m.eventTime && len(m.lines) > 0 && entry.Timestamp.Sub(m.lastReceiveTime) > m.timeout {
+		m.flushMessage()
+	}
 
 	entry.Content = strings.TrimSuffix(entry.Content, "\n")
+	raw := entry.Content
+	if !m.keepANSI {
+		entry.Content = StripANSI(entry.Content)
+	}
 	if entry.Content == "" {
 		if len(m.lines) > 0 {
-			m.add(entry)
+			m.add(entry, raw)
 		}
 		return
 	}
@@ -88,35 +206,92 @@ func (m *MultilineCollector) Add(entry LogEntry) {
 		m.flushMessage()
 		m.pythonTraceback = pythonTraceback
 	}
-	m.add(entry)
+	m.add(entry, raw)
 }
 
-func (m *MultilineCollector) add(entry LogEntry) {
-	remaining := m.limit - m.size
-	if remaining <= 0 {
+func (m *MultilineCollector) add(entry LogEntry, raw string) {
+	if m.size >= m.limit {
+		m.reportTruncation(entry)
 		return
 	}
 	if len(m.lines) == 0 {
 		m.ts = entry.Timestamp
-		m.level = GuessLevel(entry.Content)
+		m.labels = entry.Labels
+		if m.contentTimestamps {
+			ref := entry.Timestamp
+			if ref.IsZero() {
+				ref = m.clock.Now()
+			}
+			if ts, ok := m.timestampResolver.ExtractTimestamp(entry.Content, ref); ok {
+				m.ts = ts.Time
+			}
+		}
+		if m.levelRules != nil {
+			m.levelGuess = m.levelRules.GuessLevelDetailed(entry.Content)
+		} else {
+			m.levelGuess = GuessLevelDetailed(entry.Content)
+		}
+		m.level = m.levelGuess.Level
 		if m.level == LevelUnknown && entry.Level != LevelUnknown {
 			m.level = entry.Level
 		}
-		m.isFirstLineContainsTimestamp = containsTimestamp(entry.Content)
-	}
-	content := entry.Content
-	if len(content) > remaining {
-		for remaining > 0 && !utf8.RuneStart(content[remaining]) {
-			remaining--
-		}
-		if remaining == 0 {
-			return
+		m.isFirstLineContainsTimestamp = hasLeadingTimestamp(entry.Content)
+		if isRustPanic(entry.Content) {
+			m.rustPanic = true
+			m.rustPanicMessageExpected = strings.HasSuffix(entry.Content, ":")
 		}
-		content = content[:remaining]
+	}
+	content := truncate(entry.Content, m.limit-m.size)
+	if len(content) < len(entry.Content) {
+		m.reportTruncation(entry)
+	}
+	if content == "" && entry.Content != "" {
+		return
 	}
 	m.lines = append(m.lines, content)
 	m.size += len(content) + 1
-	m.lastReceiveTime = time.Now()
+	if m.keepRaw {
+		raw = truncate(raw, m.limit-m.rawSize)
+		m.rawLines = append(m.rawLines, raw)
+		m.rawSize += len(raw) + 1
+	}
+	if m.eventTime {
+		m.lastReceiveTime = entry.Timestamp
+	} else {
+		m.lastReceiveTime = m.clock.Now()
+	}
+}
+
+// reportTruncation reports the first truncated entry of the current message
+func (m *MultilineCollector) reportTruncation(entry LogEntry) {
+	if m.truncated {
+		return
+	}
+	m.truncated = true
+	m.reportError(entry, ErrTruncated)
+}
+
+func (m *MultilineCollector) reportError(entry LogEntry, err error) {
+	for _, cb := range m.errorCallbacks {
+		cb(entry, err)
+	}
+}
+
+// Watermark tells the collector that no entries older than t are expected anymore.
+// In the event-time mode, the pending message is flushed if t is far enough from its last line.
+func (m *MultilineCollector) Watermark(t time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if len(m.lines) > 0 && t.Sub(m.lastReceiveTime) > m.timeout {
+		m.flushMessage()
+	}
+}
+
+// Flush emits the pending message regardless of the timeout.
+func (m *MultilineCollector) Flush() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.flushMessage()
 }
 
 func (m *MultilineCollector) isNextMessage(l string) bool {
@@ -125,7 +300,7 @@ func (m *MultilineCollector) isNextMessage(l string) bool {
 	}
 
 	if m.isFirstLineContainsTimestamp {
-		return containsTimestamp(l)
+		return hasLeadingTimestamp(l)
 	}
 
 	if strings.HasPrefix(l, "Caused by: ") {
@@ -136,6 +311,40 @@ func (m *MultilineCollector) isNextMessage(l string) bool {
 		return false
 	}
 
+	// ruby: `from /app/x.rb:12:in 'foo'`
+	if strings.HasPrefix(l, "from ") && strings.Contains(l, ":in ") {
+		return false
+	}
+
+	// php: `Stack trace:` followed by `#0 /var/www/index.php(7): foo()` ... `#1 {main}`
+	if l == "Stack trace:" || l == "PHP Stack trace:" || isPhpFrame(l) {
+		return false
+	}
+
+	// .net: `---> System.ArgumentException: ...`, `--- End of inner exception stack trace ---`
+	if strings.HasPrefix(l, "---> ") || strings.HasPrefix(l, "--- End of ") {
+		return false
+	}
+
+	if m.rustPanic {
+		if m.rustPanicMessageExpected {
+			m.rustPanicMessageExpected = false
+			return false
+		}
+		if l == "stack backtrace:" || strings.HasPrefix(l, "note: ") {
+			return false
+		}
+	}
+
+	// elixir: `** (RuntimeError) oops` followed by indented frames and the GenServer state
+	if strings.HasPrefix(l, "** (") {
+		m.elixirException = true
+		return false
+	}
+	if m.elixirException && (strings.HasPrefix(l, "Last message") || strings.HasPrefix(l, "State: ") || strings.HasPrefix(l, "Client ")) {
+		return false
+	}
+
 	if strings.HasPrefix(l, "Traceback ") {
 		m.pythonTraceback = true
 		if m.pythonTracebackExpected {
@@ -163,11 +372,26 @@ func (m *MultilineCollector) flushMessage() {
 	if len(m.lines) == 0 {
 		return
 	}
-	content := strings.TrimSpace(strings.Join(m.lines, "\n"))
+	content := strings.Join(m.lines, "\n")
+	levelGuess := m.levelGuess
+	if trimmed := strings.TrimLeftFunc(content, unicode.IsSpace); len(trimmed) < len(content) && levelGuess.Offset >= 0 {
+		levelGuess.Offset -= len(content) - len(trimmed)
+	}
+	content = strings.TrimSpace(content)
 	msg := Message{
-		Timestamp: m.ts,
-		Content:   content,
-		Level:     m.level,
+		Timestamp:  m.ts,
+		Content:    content,
+		Level:      m.level,
+		LevelGuess: levelGuess,
+		Exception:  DetectException(content),
+		Labels:     m.labels,
+	}
+	if m.keepRaw {
+		msg.RawContent = strings.TrimSpace(strings.Join(m.rawLines, "\n"))
+	}
+	if msg.Level == LevelUnknown {
+		msg.Level = guessMessageLevel(content, msg.Exception)
+		msg.LevelInferred = msg.Level != LevelUnknown
 	}
 	m.reset()
 	m.Messages <- msg
@@ -176,9 +400,41 @@ func (m *MultilineCollector) flushMessage() {
 func (m *MultilineCollector) reset() {
 	m.ts = time.Time{}
 	m.level = LevelUnknown
+	m.levelGuess = unknownLevelGuess
+	m.labels = nil
 	m.lines = m.lines[:0]
 	m.size = 0
+	m.rawLines = m.rawLines[:0]
+	m.rawSize = 0
+	m.truncated = false
 	m.isFirstLineContainsTimestamp = false
 	m.pythonTraceback = false
 	m.pythonTracebackExpected = false
+	m.rustPanic = false
+	m.rustPanicMessageExpected = false
+	m.elixirException = false
+}
+
+// truncate cuts s to at most limit bytes without breaking UTF-8 sequences
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	if limit <= 0 {
+		return ""
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
+}
+
+// thread 'main' panicked at src/main.rs:2:5:
+// thread 'main' panicked at 'explicit panic', src/main.rs:2:5
+func isRustPanic(l string) bool {
+	return strings.HasPrefix(l, "thread '") && strings.Contains(l, "' panicked at ")
+}
+
+func isPhpFrame(l string) bool {
+	return strings.HasPrefix(l, "#") && phpFrameLineRe.MatchString(l)
 }
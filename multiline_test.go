@@ -34,6 +34,29 @@ func writeByLine(m *MultilineCollector, data string, ts time.Time) []Message {
 	return msgs
 }
 
+// collectByLine adds the lines of data to a collector in the event-time mode and flushes it,
+// so that the messages are split by their content only, regardless of the scheduling.
+func collectByLine(data string, ts time.Time, limit int, opts ...MultilineCollectorOption) []Message {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := NewMultilineCollector(ctx, time.Minute, limit, append([]MultilineCollectorOption{WithEventTime()}, opts...)...)
+	var msgs []Message
+	done := make(chan bool)
+	go func() {
+		for msg := range m.Messages {
+			msgs = append(msgs, msg)
+		}
+		done <- true
+	}()
+	for _, line := range strings.Split(data, "\n") {
+		m.Add(LogEntry{Timestamp: ts, Content: line, Level: LevelUnknown})
+		ts = ts.Add(time.Millisecond)
+	}
+	m.Flush()
+	cancel()
+	<-done
+	return msgs
+}
+
 func TestMultilineCollector(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	m := NewMultilineCollector(ctx, 10*time.Millisecond, multilineCollectorLimit)
@@ -47,6 +70,31 @@ Order response: {"timestamp":1648205755430,"status":406,"error":"Not Acceptable"
 	assert.Equal(t, strings.Split(data, "\n")[1], msgs[1].Content)
 }
 
+func TestMultilineCollectorLeadingTimestamp(t *testing.T) {
+	data := `2024-02-16 | ERROR | payment failed:
+Payment declined: amount exceeds 100.00
+the last attempt to charge the card was made at 2024-02-16 10:00:00
+2024-02-16 | INFO | payment succeeded`
+	msgs := collectByLine(data, time.Unix(0, 0), multilineCollectorLimit)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "2024-02-16 | ERROR | payment failed:\nPayment declined: amount exceeds 100.00\nthe last attempt to charge the card was made at 2024-02-16 10:00:00", msgs[0].Content)
+	assert.Equal(t, "2024-02-16 | INFO | payment succeeded", msgs[1].Content)
+
+	data = `1700000000.123 ERROR payment failed
+Payment declined
+1700000001.456 INFO payment succeeded`
+	msgs = collectByLine(data, time.Unix(0, 0), multilineCollectorLimit)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "1700000000.123 ERROR payment failed\nPayment declined", msgs[0].Content)
+
+	data = `[9:05:01] payment failed
+Payment declined
+[10:05:01] payment succeeded`
+	msgs = collectByLine(data, time.Unix(0, 0), multilineCollectorLimit)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "[9:05:01] payment failed\nPayment declined", msgs[0].Content)
+}
+
 func TestMultilineCollectorPython(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	m := NewMultilineCollector(ctx, 10*time.Millisecond, multilineCollectorLimit)
@@ -408,3 +456,162 @@ func TestMultilineCollectorLimit(t *testing.T) {
 	assert.Equal(t, 97, len(msgs[0].Content))
 	assert.True(t, utf8.ValidString(msgs[0].Content))
 }
+
+func TestMultilineCollectorErrorCallback(t *testing.T) {
+	var errs []error
+	data := "I0215 12:33:07.230967 foo\n" + strings.Repeat("  foo bar baz\n", 10) + "bad \xff\xfe line\n" + "I0215 12:33:08.230967 " + strings.Repeat("foo ", 30)
+	msgs := collectByLine(data, time.Unix(0, 0), 100, WithErrorCallback(func(entry LogEntry, err error) {
+		errs = append(errs, err)
+	}))
+	require.Len(t, msgs, 2)
+	assert.Equal(t, []error{ErrTruncated, ErrInvalidUTF8, ErrTruncated}, errs)
+}
+
+func TestMultilineCollectorEventTime(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := NewMultilineCollector(ctx, time.Second, multilineCollectorLimit, WithEventTime())
+	defer cancel()
+
+	var msgs []Message
+	done := make(chan bool)
+	go func() {
+		for msg := range m.Messages {
+			msgs = append(msgs, msg)
+		}
+		done <- true
+	}()
+
+	ts := time.Unix(100, 0)
+	m.Add(LogEntry{Timestamp: ts, Content: "foo"})
+	m.Add(LogEntry{Timestamp: ts.Add(500 * time.Millisecond), Content: "  bar"})
+	m.Add(LogEntry{Timestamp: ts.Add(2 * time.Second), Content: "  baz"})
+	m.Watermark(ts.Add(2500 * time.Millisecond))
+	m.Add(LogEntry{Timestamp: ts.Add(3 * time.Second), Content: "qux"})
+	m.Watermark(ts.Add(4 * time.Second))
+	m.Add(LogEntry{Timestamp: ts.Add(5 * time.Second), Content: "quux"})
+	m.Flush()
+	cancel()
+	<-done
+
+	require.Len(t, msgs, 4)
+	assert.Equal(t, "foo\n  bar", msgs[0].Content)
+	assert.Equal(t, ts, msgs[0].Timestamp)
+	assert.Equal(t, "baz", msgs[1].Content)
+	assert.Equal(t, "qux", msgs[2].Content)
+	assert.Equal(t, "quux", msgs[3].Content)
+}
+
+func TestMultilineCollectorRuby(t *testing.T) {
+	data := `/app/lib/worker.rb:12:in 'process': undefined method 'name' for nil (NoMethodError)
+from /app/lib/worker.rb:5:in 'block in run'
+from /app/lib/worker.rb:4:in 'each'
+from /app/lib/worker.rb:4:in 'run'
+from /app/bin/worker:8:in '<main>'
+/app/lib/worker.rb:12:in 'process': undefined method 'name' for nil (NoMethodError)
+	from /app/lib/worker.rb:5:in 'block in run'
+	from /app/bin/worker:8:in '<main>'`
+	msgs := collectByLine(data, time.Unix(0, 0), multilineCollectorLimit)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, data, msgs[0].Content+"\n"+msgs[1].Content)
+}
+
+func TestMultilineCollectorPHP(t *testing.T) {
+	data := `PHP Fatal error:  Uncaught Exception: Database is unavailable in /var/www/html/src/Db.php:21
+Stack trace:
+#0 /var/www/html/src/Repository.php(14): App\Db->connect()
+#1 /var/www/html/index.php(7): App\Repository->find(42)
+#2 {main}
+  thrown in /var/www/html/src/Db.php on line 21
+PHP Warning:  Undefined variable $user in /var/www/html/index.php on line 9`
+	msgs := collectByLine(data, time.Unix(0, 0), multilineCollectorLimit)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, data, msgs[0].Content+"\n"+msgs[1].Content)
+}
+
+func TestMultilineCollectorDotNet(t *testing.T) {
+	data := `System.InvalidOperationException: Failed to load the order
+---> System.Data.SqlClient.SqlException: Timeout expired.
+   at System.Data.SqlClient.SqlConnection.OnError(SqlException exception, Boolean breakConnection)
+   at Orders.Repository.Load(Int32 id) in /src/Orders/Repository.cs:line 42
+--- End of inner exception stack trace ---
+   at Orders.Service.Get(Int32 id) in /src/Orders/Service.cs:line 17
+--- End of stack trace from previous location ---
+   at Orders.Controller.Get(Int32 id) in /src/Orders/Controller.cs:line 25
+Unhandled exception. System.ArgumentNullException: Value cannot be null. (Parameter 'key')
+   at System.Collections.Generic.Dictionary` + "`" + `2.FindValue(TKey key)`
+	msgs := collectByLine(data, time.Unix(0, 0), multilineCollectorLimit)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, data, msgs[0].Content+"\n"+msgs[1].Content)
+}
+
+func TestMultilineCollectorRust(t *testing.T) {
+	data := `thread 'main' panicked at src/main.rs:4:5:
+called ` + "`Option::unwrap()`" + ` on a ` + "`None`" + ` value
+note: run with ` + "`RUST_BACKTRACE=1`" + ` environment variable to display a backtrace
+thread 'tokio-runtime-worker' panicked at 'index out of bounds: the len is 3 but the index is 5', src/handler.rs:17:9
+stack backtrace:
+   0: rust_begin_unwind
+             at /rustc/90c541806f23a127002de5b4038be731ba1458ca/library/std/src/panicking.rs:578:5
+   1: core::panicking::panic_bounds_check
+             at /rustc/90c541806f23a127002de5b4038be731ba1458ca/library/core/src/panicking.rs:162:5
+note: Some details are omitted, run with ` + "`RUST_BACKTRACE=full`" + ` for a verbose backtrace.
+starting server on 0.0.0.0:8080`
+	msgs := collectByLine(data, time.Unix(0, 0), multilineCollectorLimit)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, data, msgs[0].Content+"\n"+msgs[1].Content+"\n"+msgs[2].Content)
+	assert.Equal(t, "starting server on 0.0.0.0:8080", msgs[2].Content)
+}
+
+func TestMultilineCollectorElixir(t *testing.T) {
+	data := `GenServer MyApp.Worker terminating
+** (ArithmeticError) bad argument in arithmetic expression
+    (my_app 0.1.0) lib/my_app/worker.ex:21: MyApp.Worker.handle_call/3
+    (stdlib 4.3) gen_server.erl:1113: :gen_server.try_handle_call/4
+    (stdlib 4.3) gen_server.erl:1142: :gen_server.handle_msg/6
+Last message (from #PID<0.241.0>): {:divide, 1, 0}
+State: %{count: 0}
+Client #PID<0.241.0> is alive
+Application my_app exited: shutdown`
+	msgs := collectByLine(data, time.Unix(0, 0), multilineCollectorLimit)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, data, msgs[0].Content+"\n"+msgs[1].Content)
+	assert.Equal(t, "Application my_app exited: shutdown", msgs[1].Content)
+}
+
+func TestMultilineCollectorLevel(t *testing.T) {
+	data := `Exception in thread "main" java.lang.NullPointerException
+	at com.example.MyClass.methodA(MyClass.java:10)
+Starting the server
+WARN disk is almost full
+Traceback (most recent call last):
+  File "/app/main.py", line 10, in <module>
+    func()
+ConnectionError`
+	msgs := collectByLine(data, time.Unix(0, 0), multilineCollectorLimit)
+	require.Len(t, msgs, 4)
+	assert.Equal(t, LevelError, msgs[0].Level)
+	assert.Equal(t, LevelUnknown, msgs[1].Level)
+	assert.Equal(t, LevelWarning, msgs[2].Level)
+	assert.Equal(t, LevelError, msgs[3].Level)
+}
+
+func TestMultilineCollectorANSI(t *testing.T) {
+	data := "\x1b[2m2026-10-14 10:00:00\x1b[0m \x1b[31mERROR\x1b[0m request failed\n\tat com.example.App.run(App.java:10)"
+	msgs := collectByLine(data, time.Unix(0, 0), multilineCollectorLimit, WithRawContent())
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "2026-10-14 10:00:00 ERROR request failed\n\tat com.example.App.run(App.java:10)", msgs[0].Content)
+	assert.Equal(t, LevelError, msgs[0].Level)
+	assert.Equal(t, data, msgs[0].RawContent)
+
+	msgs = collectByLine(data, time.Unix(0, 0), multilineCollectorLimit, WithoutANSIStripping())
+	require.Len(t, msgs, 1)
+	assert.Equal(t, data, msgs[0].Content)
+	assert.Equal(t, "", msgs[0].RawContent)
+}
+
+func TestMultilineCollectorContentTimestamps(t *testing.T) {
+	msgs := collectByLine("no timestamp\n2005-08-09T18:31:42Z started", time.Unix(100, 0), multilineCollectorLimit, WithContentTimestamps())
+	require.Len(t, msgs, 2)
+	assert.Equal(t, time.Unix(100, 0), msgs[0].Timestamp)
+	assert.Equal(t, time.Date(2005, 8, 9, 18, 31, 42, 0, time.UTC), msgs[1].Timestamp)
+}
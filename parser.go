@@ -2,6 +2,9 @@ package logparser
 
 import (
 	"context"
+	"errors"
+	"maps"
+	"strings"
 	"sync"
 	"time"
 )
@@ -15,17 +18,29 @@ type LogEntry struct {
 	Timestamp time.Time
 	Content   string
 	Level     Level
+	// Stream is the stream the entry was written to (e.g., stdout or stderr), if known.
+	Stream string
+	// Partial is set for fragments of a line split by the container runtime (the CRI `P` tag).
+	Partial bool
+	// Labels describe the source of the entry (e.g., the syslog hostname and app-name).
+	// The parser passes them to Message.Labels and LogCounter.SampleLabels.
+	Labels map[string]string
 }
 
 type LogCounter struct {
-	Level    Level
-	Hash     string
-	Sample   string
-	Messages int
+	Level Level
+	// LevelInferred is set if the level has been derived from the message content (e.g., by the keyword classifier)
+	// rather than an explicit level token; such messages are counted separately from the ones with the same explicit level.
+	LevelInferred bool
+	Hash          string
+	Sample        string
+	// SampleLabels are the labels of the sample entry (see LogEntry.Labels).
+	SampleLabels map[string]string
+	Messages     int
 }
 
 type Parser struct {
-	decoder Decoder
+	decoder EntryDecoder
 
 	patterns              map[patternKey]*patternStat
 	patternsPerLevel      map[Level]int
@@ -33,39 +48,207 @@ type Parser struct {
 	lock                  sync.RWMutex
 
 	multilineCollector *MultilineCollector
+	partials           *partialAssembler
 
+	clock                     Clock
+	fingerprintFrames         int
+	keywordClassifier         *KeywordClassifier
+	fineGrainedLevels         bool
+	excludeLevelToken         bool
+	rawSamples                bool
+	maskDevices               bool
+	inAppPrefixes             []string
+	multilineCollectorOptions []MultilineCollectorOption
+	flushCh                   chan chan struct{}
+	countCh                   chan chan struct{}
+
+	ctx  context.Context
 	stop func()
 
-	onMsgCb OnMsgCallbackF
+	onMsgCb         OnMsgCallbackF
+	onDecodeErrorCb OnDecodeErrorCallbackF
+	errorCounters   ErrorCounters
 }
 
 type OnMsgCallbackF func(ts time.Time, level Level, patternHash string, msg string)
 
-func NewParser(ch <-chan LogEntry, decoder Decoder, onMsgCallback OnMsgCallbackF, multilineCollectorTimeout time.Duration, patternsPerLevelLimit int) *Parser {
+type OnDecodeErrorCallbackF func(entry LogEntry, err error)
+
+// badSampleLimit is the maximum size of ErrorCounters.LastBadSample
+const badSampleLimit = 1024
+
+// ErrorCounters describes the entries the parser has failed to process.
+type ErrorCounters struct {
+	// DecodeErrors is the number of entries the decoder has failed to decode.
+	DecodeErrors int
+	// InvalidUTF8 is the number of entries dropped because of invalid UTF-8.
+	InvalidUTF8 int
+	// Truncations is the number of messages (or joined partial lines) cut to the size limit.
+	Truncations int
+	// LastBadSample is the beginning of the last entry that failed to decode or contained invalid UTF-8.
+	LastBadSample string
+	// LastError is the error of the last bad entry, its message is cut to the size of LastBadSample.
+	LastError error
+}
+
+type ParserOption func(*Parser)
+
+// WithDeviceMasking makes the messages that differ only in block device names (e.g., sda1 and nvme0n1) share a pattern.
+func WithDeviceMasking() ParserOption {
+	return func(p *Parser) {
+		p.maskDevices = true
+	}
+}
+
+// WithEntryDecoder sets the decoder of the incoming entries, replacing the one passed to NewParser.
+func WithEntryDecoder(d EntryDecoder) ParserOption {
+	return func(p *Parser) {
+		p.decoder = d
+	}
+}
+
+// WithDecodeErrorCallback sets the function called for the entries the decoder has failed to decode (with the decoder's error),
+// the entries dropped because of invalid UTF-8 (ErrInvalidUTF8) and the truncated messages (ErrTruncated).
+func WithDecodeErrorCallback(cb OnDecodeErrorCallbackF) ParserOption {
+	return func(p *Parser) {
+		p.onDecodeErrorCb = cb
+	}
+}
+
+func WithMultilineCollectorOptions(opts ...MultilineCollectorOption) ParserOption {
+	return func(p *Parser) {
+		p.multilineCollectorOptions = append(p.multilineCollectorOptions, opts...)
+	}
+}
+
+// WithParserClock sets the clock used by the parser and its multiline collector.
+// Entries without a timestamp are stamped with the clock's current time.
+func WithParserClock(c Clock) ParserOption {
+	return func(p *Parser) {
+		p.clock = c
+	}
+}
+
+// WithStackTraceFingerprinting makes the parser group messages containing a stack trace by the exception type
+// and the top in-app frames instead of the message text. A frame is considered in-app if its function or file
+// contains any of the given prefixes; if none are given, well-known framework and standard library frames are skipped.
+func WithStackTraceFingerprinting(frames int, inAppPrefixes ...string) ParserOption {
+	return func(p *Parser) {
+		if frames <= 0 {
+			frames = defaultFingerprintFrames
+		}
+		p.fingerprintFrames = frames
+		p.inAppPrefixes = inAppPrefixes
+	}
+}
+
+// WithParserLevelRules makes the parser use user-defined rules in addition to the built-in level heuristics.
+func WithParserLevelRules(r *LevelRules) ParserOption {
+	return WithMultilineCollectorOptions(WithLevelRules(r))
+}
+
+// WithParserContentTimestamps makes the parser use timestamps extracted from messages instead of the entry timestamps.
+func WithParserContentTimestamps() ParserOption {
+	return WithMultilineCollectorOptions(WithContentTimestamps())
+}
+
+// WithParserTimestampLocation sets the time zone of the extracted timestamps that don't specify one.
+func WithParserTimestampLocation(loc *time.Location) ParserOption {
+	return WithMultilineCollectorOptions(WithTimestampLocation(loc))
+}
+
+// WithKeywordClassifier enables assigning heuristic levels to messages without an explicit level.
+func WithKeywordClassifier(c *KeywordClassifier) ParserOption {
+	return func(p *Parser) {
+		p.keywordClassifier = c
+	}
+}
+
+// WithFineGrainedLevels makes the parser report Trace, Notice, Alert and Emergency levels.
+// By default, they are reported as their coarse counterparts to keep the metrics backward compatible.
+func WithFineGrainedLevels() ParserOption {
+	return func(p *Parser) {
+		p.fineGrainedLevels = true
+	}
+}
+
+// WithLevelTokenExcludedFromPatterns removes the level token found by GuessLevelDetailed before building patterns,
+// so that messages differing only in the spelling of the level (e.g., `E`, `ERR`, `[error]`) share a pattern.
+func WithLevelTokenExcludedFromPatterns() ParserOption {
+	return func(p *Parser) {
+		p.excludeLevelToken = true
+	}
+}
+
+// WithRawSamples makes the parser keep samples in their original form, e.g., with ANSI color sequences,
+// while patterns are still built from the preprocessed content.
+func WithRawSamples() ParserOption {
+	return func(p *Parser) {
+		p.rawSamples = true
+		p.multilineCollectorOptions = append(p.multilineCollectorOptions, WithRawContent())
+	}
+}
+
+func NewParser(ch <-chan LogEntry, decoder Decoder, onMsgCallback OnMsgCallbackF, multilineCollectorTimeout time.Duration, patternsPerLevelLimit int, opts ...ParserOption) *Parser {
 	p := &Parser{
-		decoder:               decoder,
+		decoder:               EntryDecoderOf(decoder),
 		patterns:              map[patternKey]*patternStat{},
 		patternsPerLevel:      map[Level]int{},
 		patternsPerLevelLimit: patternsPerLevelLimit,
 		onMsgCb:               onMsgCallback,
+		flushCh:               make(chan chan struct{}),
+		countCh:               make(chan chan struct{}),
+		clock:                 realClock{},
+		partials:              newPartialAssembler(multilineCollectorTimeout, multilineCollectorLimit+1), // one byte over the limit lets the collector report the truncation
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.multilineCollectorOptions = append([]MultilineCollectorOption{WithClock(p.clock)}, p.multilineCollectorOptions...)
+	p.multilineCollectorOptions = append(p.multilineCollectorOptions, WithErrorCallback(p.error))
 	ctx, stop := context.WithCancel(context.Background())
-	p.stop = stop
-	p.multilineCollector = NewMultilineCollector(ctx, multilineCollectorTimeout, multilineCollectorLimit)
+	p.ctx, p.stop = ctx, stop
+	p.multilineCollector = NewMultilineCollector(ctx, multilineCollectorTimeout, multilineCollectorLimit, p.multilineCollectorOptions...)
 
 	go func() {
-		var err error
+		ticker := p.clock.NewTicker(multilineCollectorTimeout)
+		defer ticker.Stop()
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case entry := <-ch:
-				if p.decoder != nil {
-					if entry.Content, err = p.decoder.Decode(entry.Content); err != nil {
-						continue
+			case entry, ok := <-ch:
+				if !ok { // a closed channel is never selected again
+					ch = nil
+					continue
+				}
+				p.add(entry)
+			case t := <-ticker.C():
+				for _, entry := range p.partials.expired(t) {
+					p.multilineCollector.Add(entry)
+				}
+			case done := <-p.flushCh:
+				for drained := false; !drained; {
+					select {
+					case entry, ok := <-ch:
+						if !ok {
+							ch = nil
+							continue
+						}
+						p.add(entry)
+					default:
+						drained = true
 					}
 				}
-				p.multilineCollector.Add(entry)
+				for _, entry := range p.partials.flush() {
+					p.multilineCollector.Add(entry)
+				}
+				p.multilineCollector.Flush()
+				// the flushed messages are in the buffer of Messages now, the counting goroutine reports when they are counted
+				select {
+				case <-ctx.Done():
+				case p.countCh <- done:
+				}
 			}
 		}
 	}()
@@ -77,6 +260,20 @@ func NewParser(ch <-chan LogEntry, decoder Decoder, onMsgCallback OnMsgCallbackF
 				return
 			case msg := <-p.multilineCollector.Messages:
 				p.inc(msg)
+			case done := <-p.countCh:
+				for drained := false; !drained; {
+					select {
+					case msg, ok := <-p.multilineCollector.Messages:
+						if !ok {
+							drained = true
+							continue
+						}
+						p.inc(msg)
+					default:
+						drained = true
+					}
+				}
+				close(done)
 			}
 		}
 	}()
@@ -84,16 +281,81 @@ func NewParser(ch <-chan LogEntry, decoder Decoder, onMsgCallback OnMsgCallbackF
 	return p
 }
 
+func (p *Parser) add(entry LogEntry) {
+	if p.decoder != nil {
+		decoded, err := p.decoder.DecodeEntry(entry.Content)
+		if err != nil {
+			p.error(entry, err)
+			return
+		}
+		entry = mergeEntry(decoded, entry)
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = p.clock.Now()
+	}
+	var complete bool
+	if entry, complete = p.partials.add(entry, p.clock.Now()); !complete {
+		return
+	}
+	p.multilineCollector.Add(entry)
+}
+
+// mergeEntry fills the fields the decoder hasn't found from the received entry.
+// The labels are merged, the decoded ones win.
+func mergeEntry(decoded, received LogEntry) LogEntry {
+	if decoded.Timestamp.IsZero() {
+		decoded.Timestamp = received.Timestamp
+	}
+	if decoded.Level == LevelUnknown {
+		decoded.Level = received.Level
+	}
+	if decoded.Stream == "" {
+		decoded.Stream = received.Stream
+	}
+	if len(received.Labels) > 0 {
+		labels := make(map[string]string, len(received.Labels)+len(decoded.Labels))
+		maps.Copy(labels, received.Labels)
+		maps.Copy(labels, decoded.Labels)
+		decoded.Labels = labels
+	}
+	return decoded
+}
+
+// Flush processes the entries already queued in the input channel and emits the pending multiline message.
+// The messages are counted by the time it returns, so GetCounters can be called right after it.
+func (p *Parser) Flush() {
+	done := make(chan struct{})
+	select {
+	case <-p.ctx.Done():
+		return
+	case p.flushCh <- done:
+	}
+	select {
+	case <-p.ctx.Done():
+	case <-done:
+	}
+}
+
 func (p *Parser) Stop() {
 	p.stop()
 }
 
 func (p *Parser) inc(msg Message) {
+	if msg.Level == LevelUnknown && p.keywordClassifier != nil {
+		if msg.Level = p.keywordClassifier.Classify(msg.Content); msg.Level != LevelUnknown {
+			msg.LevelInferred = true
+		}
+	}
+
+	if !p.fineGrainedLevels {
+		msg.Level = msg.Level.Coarse()
+	}
+
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	if msg.Level == LevelUnknown || msg.Level == LevelDebug || msg.Level == LevelInfo {
-		key := patternKey{level: msg.Level, hash: ""}
+	if coarse := msg.Level.Coarse(); coarse == LevelUnknown || coarse == LevelDebug || coarse == LevelInfo {
+		key := patternKey{level: msg.Level, hash: "", inferred: msg.LevelInferred}
 		if stat := p.patterns[key]; stat == nil {
 			p.patterns[key] = &patternStat{}
 		}
@@ -104,21 +366,35 @@ func (p *Parser) inc(msg Message) {
 		return
 	}
 
-	pattern := NewPattern(msg.Content)
-	stat, key := p.getPatternStat(msg.Level, pattern, msg.Content)
+	var pattern *Pattern
+	if p.fingerprintFrames > 0 {
+		pattern = stackTraceFingerprint(msg.Content, msg.Exception, p.fingerprintFrames, p.inAppPrefixes)
+	}
+	if pattern == nil {
+		content := msg.Content
+		if g := msg.LevelGuess; p.excludeLevelToken && g.Offset >= 0 && g.Offset+g.Length <= len(content) {
+			content = content[:g.Offset] + " " + content[g.Offset+g.Length:]
+		}
+		pattern = newPattern(content, p.maskDevices)
+	}
+	sample := msg.Content
+	if p.rawSamples && msg.RawContent != "" {
+		sample = msg.RawContent
+	}
+	stat, key := p.getPatternStat(msg.Level, msg.LevelInferred, pattern, sample, msg.Labels)
 	if p.onMsgCb != nil {
 		p.onMsgCb(msg.Timestamp, msg.Level, key.hash, msg.Content)
 	}
 	stat.messages++
 }
 
-func (p *Parser) getPatternStat(level Level, pattern *Pattern, sample string) (*patternStat, patternKey) {
-	key := patternKey{level: level, hash: pattern.Hash()}
+func (p *Parser) getPatternStat(level Level, inferred bool, pattern *Pattern, sample string, labels map[string]string) (*patternStat, patternKey) {
+	key := patternKey{level: level, hash: pattern.Hash(), inferred: inferred}
 	if stat := p.patterns[key]; stat != nil {
 		return stat, key
 	}
 	for k, ps := range p.patterns {
-		if k.level != level || ps.pattern == nil {
+		if k.level != level || k.inferred != inferred || ps.pattern == nil || ps.pattern.exact || pattern.exact {
 			continue
 		}
 		if ps.pattern.WeakEqual(pattern) {
@@ -127,7 +403,7 @@ func (p *Parser) getPatternStat(level Level, pattern *Pattern, sample string) (*
 	}
 
 	if p.patternsPerLevel[level] >= p.patternsPerLevelLimit {
-		fallbackKey := patternKey{level: level, hash: unclassifiedPatternHash}
+		fallbackKey := patternKey{level: level, hash: unclassifiedPatternHash, inferred: inferred}
 		stat := p.patterns[fallbackKey]
 		if stat == nil {
 			stat = &patternStat{sample: unclassifiedPatternLabel}
@@ -136,7 +412,7 @@ func (p *Parser) getPatternStat(level Level, pattern *Pattern, sample string) (*
 		return stat, fallbackKey
 	}
 
-	stat := &patternStat{pattern: pattern, sample: sample}
+	stat := &patternStat{pattern: pattern, sample: sample, sampleLabels: labels}
 	p.patterns[key] = stat
 	p.patternsPerLevel[level]++
 	return stat, key
@@ -147,18 +423,55 @@ func (p *Parser) GetCounters() []LogCounter {
 	defer p.lock.RUnlock()
 	res := make([]LogCounter, 0, len(p.patterns))
 	for k, ps := range p.patterns {
-		res = append(res, LogCounter{Level: k.level, Hash: k.hash, Sample: ps.sample, Messages: ps.messages})
+		res = append(res, LogCounter{Level: k.level, LevelInferred: k.inferred, Hash: k.hash, Sample: ps.sample, SampleLabels: ps.sampleLabels, Messages: ps.messages})
 	}
 	return res
 }
 
+// GetErrorCounters returns the counters of the entries the parser has failed to process.
+func (p *Parser) GetErrorCounters() ErrorCounters {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.errorCounters
+}
+
+func (p *Parser) error(entry LogEntry, err error) {
+	p.lock.Lock()
+	switch {
+	case errors.Is(err, ErrTruncated):
+		p.errorCounters.Truncations++
+	case errors.Is(err, ErrInvalidUTF8):
+		p.errorCounters.InvalidUTF8++
+	default:
+		p.errorCounters.DecodeErrors++
+	}
+	if !errors.Is(err, ErrTruncated) {
+		p.errorCounters.LastBadSample = badSample(entry.Content)
+		p.errorCounters.LastError = err
+		if msg := err.Error(); len(msg) > badSampleLimit { // the decoders' errors may include the whole entry
+			p.errorCounters.LastError = errors.New(badSample(msg))
+		}
+	}
+	p.lock.Unlock()
+	if p.onDecodeErrorCb != nil {
+		p.onDecodeErrorCb(entry, err)
+	}
+}
+
+// badSample copies the beginning of s, so that the rest of the entry can be garbage collected
+func badSample(s string) string {
+	return strings.Clone(strings.ToValidUTF8(truncate(s, badSampleLimit), "\uFFFD"))
+}
+
 type patternKey struct {
-	level Level
-	hash  string
+	level    Level
+	hash     string
+	inferred bool
 }
 
 type patternStat struct {
-	pattern  *Pattern
-	sample   string
-	messages int
+	pattern      *Pattern
+	sample       string
+	sampleLabels map[string]string
+	messages     int
 }
@@ -2,6 +2,7 @@ package logparser
 
 import (
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -42,3 +43,235 @@ func TestParserCardinalityLimit(t *testing.T) {
 	assert.Equal(t, unclassifiedPatternLabel, counters[2].Sample)
 	assert.Equal(t, unclassifiedPatternHash, counters[2].Hash)
 }
+
+// parseEntries feeds the entries to a parser created with the given options, flushing it after each entry,
+// and returns the counters sorted by sample and the levels of the messages.
+func parseEntries(t *testing.T, entries []LogEntry, opts ...ParserOption) ([]LogCounter, []Level) {
+	t.Helper()
+	ch := make(chan LogEntry)
+	var levels []Level
+	p := NewParser(ch, nil, func(ts time.Time, level Level, patternHash string, msg string) {
+		levels = append(levels, level)
+	}, time.Minute, 10, opts...)
+	defer p.Stop()
+	for _, entry := range entries {
+		ch <- entry
+		p.Flush()
+	}
+	counters := p.GetCounters()
+	sort.Slice(counters, func(i, j int) bool { return counters[i].Sample < counters[j].Sample })
+	return counters, levels
+}
+
+func entriesOf(contents ...string) []LogEntry {
+	var res []LogEntry
+	for _, c := range contents {
+		res = append(res, LogEntry{Content: c})
+	}
+	return res
+}
+
+func TestParserStackTraceFingerprinting(t *testing.T) {
+	counters, _ := parseEntries(t, entriesOf(
+		"java.lang.IllegalArgumentException: user 1 not found\n\tat com.example.Users.get(Users.java:10)",
+		"java.lang.IllegalArgumentException: account id is too long\n\tat com.example.Users.get(Users.java:12)",
+		"java.lang.IllegalArgumentException: user 1 not found\n\tat com.example.Accounts.get(Accounts.java:10)",
+		"ERROR no stack trace here",
+	), WithStackTraceFingerprinting(3))
+	require.Len(t, counters, 3)
+	assert.Equal(t, "ERROR no stack trace here", counters[0].Sample)
+	assert.Equal(t, 1, counters[1].Messages)
+	assert.Equal(t, 2, counters[2].Messages)
+}
+
+func TestParserKeywordClassifier(t *testing.T) {
+	_, levels := parseEntries(t, entriesOf("Failed to connect to db"))
+	assert.Equal(t, []Level{LevelUnknown}, levels)
+
+	counters, levels := parseEntries(t, entriesOf("Failed to connect to db", "Server started", "ERROR Failed to connect to db"),
+		WithKeywordClassifier(NewKeywordClassifier()))
+	assert.Equal(t, []Level{LevelError, LevelUnknown, LevelError}, levels)
+	require.Len(t, counters, 3)
+	assert.Equal(t, LogCounter{Level: LevelUnknown, Messages: 1}, counters[0])
+	assert.Equal(t, LogCounter{Level: LevelError, Hash: counters[1].Hash, Sample: "ERROR Failed to connect to db", Messages: 1}, counters[1])
+	assert.Equal(t, LogCounter{Level: LevelError, LevelInferred: true, Hash: counters[2].Hash, Sample: "Failed to connect to db", Messages: 1}, counters[2])
+}
+
+func TestParserFineGrainedLevels(t *testing.T) {
+	entries := []LogEntry{{Content: "[emerg] disk failure"}, {Content: "trace message", Level: LevelTrace}}
+
+	counters, levels := parseEntries(t, entries)
+	assert.Equal(t, []Level{LevelCritical, LevelDebug}, levels)
+	require.Len(t, counters, 2)
+	assert.Equal(t, LevelDebug, counters[0].Level)
+	assert.Equal(t, LevelCritical, counters[1].Level)
+
+	counters, levels = parseEntries(t, entries, WithFineGrainedLevels())
+	assert.Equal(t, []Level{LevelEmergency, LevelTrace}, levels)
+	require.Len(t, counters, 2)
+	assert.Equal(t, LogCounter{Level: LevelTrace, Messages: 1}, counters[0])
+	assert.Equal(t, LevelEmergency, counters[1].Level)
+	assert.Equal(t, "[emerg] disk failure", counters[1].Sample)
+}
+
+func TestParserLevelTokenExcludedFromPatterns(t *testing.T) {
+	entries := entriesOf("ERR failed to connect to primary", "ERROR failed to connect to replica")
+	counters, _ := parseEntries(t, entries)
+	assert.Len(t, counters, 2)
+	counters, _ = parseEntries(t, entries, WithLevelTokenExcludedFromPatterns())
+	assert.Len(t, counters, 1)
+}
+
+type legacyDecoder struct{}
+
+func (legacyDecoder) Decode(src string) (string, error) {
+	return src + "!", nil
+}
+
+func TestParserEntryDecoder(t *testing.T) {
+	parse := func(decoder Decoder, entry LogEntry, opts ...ParserOption) (time.Time, string) {
+		t.Helper()
+		ch := make(chan LogEntry)
+		var ts time.Time
+		var msg string
+		p := NewParser(ch, decoder, func(t time.Time, level Level, patternHash string, m string) {
+			ts, msg = t, m
+		}, time.Second, 10, opts...)
+		defer p.Stop()
+		ch <- entry
+		p.Flush()
+		return ts, msg
+	}
+	received := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	ts, msg := parse(DockerJsonDecoder{}, LogEntry{Timestamp: received, Content: `{"log":"hello\n","stream":"stderr","time":"2026-10-14T11:59:59.5Z"}`})
+	assert.Equal(t, time.Date(2026, 10, 14, 11, 59, 59, 500000000, time.UTC), ts)
+	assert.Equal(t, "hello", msg)
+
+	ts, msg = parse(legacyDecoder{}, LogEntry{Timestamp: received, Content: "hello"})
+	assert.Equal(t, received, ts)
+	assert.Equal(t, "hello!", msg)
+
+	ts, msg = parse(nil, LogEntry{Timestamp: received, Content: "2026-10-14T11:59:59Z stdout F hello"}, WithEntryDecoder(CriDecoder{}))
+	assert.Equal(t, time.Date(2026, 10, 14, 11, 59, 59, 0, time.UTC), ts)
+	assert.Equal(t, "hello", msg)
+}
+
+func TestParserCriPartialEntries(t *testing.T) {
+	ch := make(chan LogEntry)
+	var msgs []string
+	p := NewParser(ch, CriDecoder{}, func(ts time.Time, level Level, patternHash string, msg string) {
+		msgs = append(msgs, msg)
+	}, time.Second, 10)
+	defer p.Stop()
+
+	ch <- LogEntry{Content: "2026-10-14T11:59:59Z stdout P ERROR failed to "}
+	ch <- LogEntry{Content: "2026-10-14T11:59:59Z stderr F WARNING disk is almost full"}
+	ch <- LogEntry{Content: "2026-10-14T11:59:59Z stdout P connect to "}
+	ch <- LogEntry{Content: "2026-10-14T11:59:59Z stdout F db"}
+	p.Flush()
+	assert.Equal(t, []string{"WARNING disk is almost full", "ERROR failed to connect to db"}, msgs)
+}
+
+func TestParserDockerPartialEntries(t *testing.T) {
+	ch := make(chan LogEntry)
+	var msgs []string
+	var decodeErrors []string
+	p := NewParser(ch, DockerJsonDecoder{}, func(ts time.Time, level Level, patternHash string, msg string) {
+		msgs = append(msgs, msg)
+	}, time.Second, 10, WithDecodeErrorCallback(func(entry LogEntry, err error) {
+		decodeErrors = append(decodeErrors, entry.Content)
+	}))
+	defer p.Stop()
+
+	ch <- LogEntry{Content: `{"log":"ERROR failed to ","stream":"stdout","time":"2026-10-14T11:59:59Z"}`}
+	ch <- LogEntry{Content: `{"log":"WARNING disk is almost full\n","stream":"stderr","time":"2026-10-14T11:59:59Z"}`}
+	ch <- LogEntry{Content: `not a json`}
+	ch <- LogEntry{Content: `{"log":"connect to ","stream":"stdout","time":"2026-10-14T11:59:59Z"}`}
+	ch <- LogEntry{Content: `{"log":"db\n","stream":"stdout","time":"2026-10-14T11:59:59Z"}`}
+	p.Flush()
+	assert.Equal(t, []string{"WARNING disk is almost full", "ERROR failed to connect to db"}, msgs)
+	assert.Equal(t, []string{"not a json"}, decodeErrors)
+}
+
+func TestParserFlush(t *testing.T) {
+	ch := make(chan LogEntry, 10)
+	p := NewParser(ch, nil, nil, time.Minute, 10)
+	defer p.Stop()
+
+	ch <- LogEntry{Content: "ERROR failed to connect to db"}
+	ch <- LogEntry{Content: "WARNING disk is almost full"}
+	ch <- LogEntry{Content: "ERROR failed to connect to db"}
+	close(ch)
+	p.Flush()
+
+	counters := p.GetCounters()
+	sort.Slice(counters, func(i, j int) bool { return counters[i].Sample < counters[j].Sample })
+	require.Len(t, counters, 2)
+	assert.Equal(t, 2, counters[0].Messages)
+	assert.Equal(t, 1, counters[1].Messages)
+
+	p.Flush() // the closed input channel is not read again
+	assert.Len(t, p.GetCounters(), 2)
+}
+
+func TestParserLabels(t *testing.T) {
+	ch := make(chan LogEntry)
+	p := NewParser(ch, SyslogDecoder{}, nil, time.Second, 10)
+	defer p.Stop()
+
+	ch <- LogEntry{
+		Content: "<11>1 2026-10-14T10:00:00Z web-1 nginx 1234 - - connect() failed",
+		Labels:  map[string]string{"pod": "nginx-0", "host": "node-1"},
+	}
+	p.Flush()
+	counters := p.GetCounters()
+	require.Len(t, counters, 1)
+	assert.Equal(t, "connect() failed", counters[0].Sample)
+	assert.Equal(t, map[string]string{"pod": "nginx-0", "host": "web-1", "app": "nginx", "procid": "1234"}, counters[0].SampleLabels)
+}
+
+func TestParserErrorCounters(t *testing.T) {
+	ch := make(chan LogEntry)
+	var errs []error
+	p := NewParser(ch, CriDecoder{}, nil, time.Second, 10, WithDecodeErrorCallback(func(entry LogEntry, err error) {
+		errs = append(errs, err)
+	}))
+	defer p.Stop()
+
+	ch <- LogEntry{Content: "garbage"}
+	ch <- LogEntry{Content: "2026-10-14T11:59:59Z stdout F bad \xff line"}
+	ch <- LogEntry{Content: "2026-10-14T11:59:59Z stdout P " + strings.Repeat("x", multilineCollectorLimit)}
+	ch <- LogEntry{Content: "2026-10-14T11:59:59Z stdout F tail"}
+	ch <- LogEntry{Content: "2026-10-14T11:59:59Z stdout F \tat com.example.App.run(App.java:10)"}
+	p.Flush()
+
+	c := p.GetErrorCounters()
+	assert.Equal(t, 1, c.DecodeErrors)
+	assert.Equal(t, 1, c.InvalidUTF8)
+	assert.Equal(t, 1, c.Truncations)
+	assert.Equal(t, "bad � line", c.LastBadSample)
+	assert.Equal(t, ErrInvalidUTF8, c.LastError)
+	require.Len(t, errs, 3)
+	assert.EqualError(t, errs[0], "unexpected entry format: garbage")
+	assert.Equal(t, []error{ErrInvalidUTF8, ErrTruncated}, errs[1:])
+
+	ch <- LogEntry{Content: strings.Repeat("garbage", 1000)}
+	p.Flush()
+	c = p.GetErrorCounters()
+	assert.Equal(t, 2, c.DecodeErrors)
+	assert.Len(t, c.LastBadSample, badSampleLimit)
+	assert.Len(t, c.LastError.Error(), badSampleLimit)
+	assert.True(t, strings.HasPrefix(c.LastError.Error(), "unexpected entry format: garbage"))
+}
+
+func TestParserDeviceMasking(t *testing.T) {
+	entries := []LogEntry{
+		{Content: "disk sda failed, replace disk sda", Level: LevelError},
+		{Content: "disk sdb failed, replace disk sdb", Level: LevelError},
+	}
+	counters, _ := parseEntries(t, entries)
+	assert.Len(t, counters, 2)
+	counters, _ = parseEntries(t, entries, WithDeviceMasking())
+	assert.Len(t, counters, 1)
+}
@@ -0,0 +1,73 @@
+package logparser
+
+import (
+	"strings"
+	"time"
+)
+
+// partialAssembler joins the fragments of lines split by the container runtime (entries with Partial set)
+// into a single entry. Fragments are buffered per stream until the terminating (non-partial) entry arrives.
+// The joined content is capped at limit bytes, and fragments without the terminating entry are emitted after timeout.
+type partialAssembler struct {
+	timeout time.Duration
+	limit   int
+	streams map[string]*partialEntry
+}
+
+type partialEntry struct {
+	entry    LogEntry
+	content  strings.Builder
+	received time.Time
+}
+
+func newPartialAssembler(timeout time.Duration, limit int) *partialAssembler {
+	return &partialAssembler{timeout: timeout, limit: limit, streams: map[string]*partialEntry{}}
+}
+
+// add returns the complete entry if the given entry isn't a fragment or terminates one.
+func (a *partialAssembler) add(entry LogEntry, now time.Time) (LogEntry, bool) {
+	pe := a.streams[entry.Stream]
+	if pe == nil {
+		if !entry.Partial {
+			return entry, true
+		}
+		pe = &partialEntry{entry: entry}
+		a.streams[entry.Stream] = pe
+	}
+	pe.content.WriteString(truncate(entry.Content, a.limit-pe.content.Len()))
+	pe.received = now
+	if entry.Partial {
+		return LogEntry{}, false
+	}
+	delete(a.streams, entry.Stream)
+	return pe.complete(), true
+}
+
+// expired returns the buffered fragments that haven't been terminated within the timeout.
+func (a *partialAssembler) expired(now time.Time) []LogEntry {
+	var res []LogEntry
+	for stream, pe := range a.streams {
+		if now.Sub(pe.received) > a.timeout {
+			res = append(res, pe.complete())
+			delete(a.streams, stream)
+		}
+	}
+	return res
+}
+
+// flush returns all the buffered fragments.
+func (a *partialAssembler) flush() []LogEntry {
+	var res []LogEntry
+	for stream, pe := range a.streams {
+		res = append(res, pe.complete())
+		delete(a.streams, stream)
+	}
+	return res
+}
+
+func (pe *partialEntry) complete() LogEntry {
+	entry := pe.entry
+	entry.Content = pe.content.String()
+	entry.Partial = false
+	return entry
+}
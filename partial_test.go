@@ -0,0 +1,41 @@
+package logparser
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartialAssembler(t *testing.T) {
+	now := time.Unix(100, 0)
+	a := newPartialAssembler(time.Second, 12)
+
+	entry, ok := a.add(LogEntry{Content: "hello", Stream: "stdout"}, now)
+	assert.True(t, ok)
+	assert.Equal(t, "hello", entry.Content)
+
+	_, ok = a.add(LogEntry{Timestamp: time.Unix(1, 0), Content: "foo ", Stream: "stdout", Partial: true}, now)
+	assert.False(t, ok)
+	_, ok = a.add(LogEntry{Timestamp: time.Unix(2, 0), Content: "ERROR", Stream: "stderr", Partial: true}, now)
+	assert.False(t, ok)
+	_, ok = a.add(LogEntry{Timestamp: time.Unix(3, 0), Content: "bar ", Stream: "stdout", Partial: true}, now)
+	assert.False(t, ok)
+	entry, ok = a.add(LogEntry{Timestamp: time.Unix(4, 0), Content: "baz qux", Stream: "stdout"}, now)
+	require.True(t, ok)
+	assert.Equal(t, LogEntry{Timestamp: time.Unix(1, 0), Content: "foo bar baz ", Stream: "stdout"}, entry)
+
+	assert.Empty(t, a.expired(now.Add(time.Second)))
+	expired := a.expired(now.Add(2 * time.Second))
+	require.Len(t, expired, 1)
+	assert.Equal(t, LogEntry{Timestamp: time.Unix(2, 0), Content: "ERROR", Stream: "stderr"}, expired[0])
+
+	a.add(LogEntry{Content: "foo", Stream: "stdout", Partial: true}, now)
+	a.add(LogEntry{Content: "bar", Stream: "stderr", Partial: true}, now)
+	flushed := a.flush()
+	sort.Slice(flushed, func(i, j int) bool { return flushed[i].Content < flushed[j].Content })
+	assert.Equal(t, []LogEntry{{Content: "bar", Stream: "stderr"}, {Content: "foo", Stream: "stdout"}}, flushed)
+	assert.Empty(t, a.flush())
+}
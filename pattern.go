@@ -37,12 +37,19 @@ var (
 	hexWithPrefix = regexp.MustCompile(`^0x[a-fA-F0-9]+$`)
 	hex           = regexp.MustCompile(`^[a-fA-F0-9]{4,}$`)
 	uuid          = regexp.MustCompile(`^[a-fA-F0-9]{8}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{12}$`)
+
+	// sda1, xvdf2, nvme0n1p2, mmcblk0p1, loop3, dm-0, ata1.00
+	device = regexp.MustCompile(`^(?:(sd|vd|xvd)[a-z]{1,2}\d+|(nvme)\d+(?:n\d+)?(?:p\d+)?|(mmcblk)\d+(?:p\d+)?|(loop|md|nbd|sr)\d+|(ata)\d+(?:\.\d+)?|(dm)-\d+)$`)
+	// sda, vdb, xvdf look like ordinary words (sdk, vdso), so they are masked only after deviceContextWords
+	diskDevice         = regexp.MustCompile(`^(sd|vd|xvd)[a-z]{1,2}$`)
+	deviceContextWords = map[string]bool{"dev": true, "device": true, "disk": true, "drive": true}
 )
 
 type Pattern struct {
 	words []string
 	str   *string
 	hash  *string
+	exact bool
 }
 
 func (p *Pattern) String() string {
@@ -87,17 +94,28 @@ func (p *Pattern) WeakEqual(other *Pattern) bool {
 }
 
 func NewPattern(input string) *Pattern {
+	return newPattern(input, false)
+}
+
+// newPattern builds a pattern, replacing the block device names with their types (sdb1 -> sd) if maskDevices is set
+func newPattern(input string, maskDevices bool) *Pattern {
 	pattern := &Pattern{}
 	buf := buffers.Get().(*bytes.Buffer)
 	buf.Reset()
+	prev := ""
 	for _, p := range strings.Fields(removeQuotedAndBrackets(input, buf)) {
 		p = strings.TrimRight(p, "=:],;")
+		afterDeviceWord := deviceContextWords[strings.ToLower(prev)]
+		prev = p
 		if len(p) < patterMinWordLen {
 			continue
 		}
 		if hexWithPrefix.MatchString(p) || hex.MatchString(p) || uuid.MatchString(p) {
 			continue
 		}
+		if maskDevices {
+			p = maskDevice(p, afterDeviceWord)
+		}
 		p = removeDigits(p, buf)
 		if !isWord(p) {
 			continue
@@ -145,6 +163,22 @@ func isWord(s string) bool {
 	return firstLast == 2
 }
 
+func maskDevice(s string, afterDeviceWord bool) string {
+	m := device.FindStringSubmatch(s)
+	if m == nil && afterDeviceWord {
+		m = diskDevice.FindStringSubmatch(s)
+	}
+	if m == nil {
+		return s
+	}
+	for _, g := range m[1:] {
+		if g != "" {
+			return g
+		}
+	}
+	return s
+}
+
 func removeDigits(s string, buf *bytes.Buffer) string {
 	buf.Reset()
 	for _, r := range s {
@@ -82,6 +82,28 @@ func TestPattern(t *testing.T) {
 	)
 }
 
+func TestPatternMaskDevices(t *testing.T) {
+	for _, w := range []string{"sdb1", "xvdf2", "nvme0n1", "nvme1n1p2", "mmcblk0p1", "loop12", "dm-3", "ata1.00", "md127"} {
+		assert.NotEqual(t, w, maskDevice(w, false), w)
+	}
+	for _, w := range []string{"sda", "vdb", "xvdf"} {
+		assert.Equal(t, w, maskDevice(w, false), w)
+		assert.NotEqual(t, w, maskDevice(w, true), w)
+	}
+	for _, w := range []string{"disk", "sdk-version", "nvme", "loops", "data1", "hdfs", "hdmi", "hdr", "hdd", "sdk", "sdks", "vdso", "vdi"} {
+		assert.Equal(t, w, maskDevice(w, false), w)
+	}
+	assert.Equal(t, "failed to load sdk from hdfs", newPattern("failed to load sdk from hdfs", true).String())
+	assert.Equal(t, "error dev sd sector", newPattern("I/O error, dev sdc, sector 1234", true).String())
+	assert.Equal(t, "EXT-fs error device sd reading directory", newPattern("EXT4-fs error (inode 5) device sdb1: reading directory", true).String())
+	assert.Equal(t,
+		newPattern("blk_update_request: I/O error, dev nvme0n1, sector 1234", true).String(),
+		newPattern("blk_update_request: I/O error, dev nvme1n1, sector 5678", true).String())
+	assert.NotEqual(t,
+		NewPattern("blk_update_request: I/O error, dev sda, sector 1234").String(),
+		NewPattern("blk_update_request: I/O error, dev sdb, sector 5678").String())
+}
+
 func TestPatternWeakEqual(t *testing.T) {
 	assert.True(t, NewPattern("foo one baz").WeakEqual(NewPattern("foo two baz")))
 	assert.True(t, NewPattern("foo baz one").WeakEqual(NewPattern("foo baz two")))
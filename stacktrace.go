@@ -0,0 +1,336 @@
+package logparser
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	javaFrameRe   = regexp.MustCompile(`^\s+at ([^\s(]+)\(([^:)]*)(?::(\d+))?\)`)
+	dotnetFrameRe = regexp.MustCompile(`^\s+at ([^(]+)\([^)]*\)(?: in (.+):line (\d+))?`)
+	nodeFrameRe   = regexp.MustCompile(`^\s+at (?:(.+?) \()?(.+?):(\d+):\d+\)?$`)
+	pythonFrameRe = regexp.MustCompile(`^\s+File "([^"]+)", line (\d+), in (.+)$`)
+	goFileRe      = regexp.MustCompile(`^\s+(\S+):(\d+)(?: \+0x[0-9a-f]+)?$`)
+	rubyFrameRe   = regexp.MustCompile(`^\s*(?:from )?(.+?):(\d+):in [` + "`" + `']([^']+)'`)
+	phpFrameRe    = regexp.MustCompile(`^#\d+ (?:(.+)\((\d+)\): )?(.+)$`)
+	javaElidedRe  = regexp.MustCompile(`^\s+\.\.\. (\d+) more$`)
+
+	frameworkFrames = map[Language][]string{
+		LanguageJava:   {"java.", "javax.", "jdk.", "sun.", "com.sun.", "kotlin.", "scala.", "org.springframework.", "org.apache.", "org.hibernate.", "org.eclipse.", "org.mortbay.", "io.netty.", "reactor.", "$Proxy"},
+		LanguageDotNet: {"System.", "Microsoft."},
+		LanguageNodeJS: {"node_modules/", "node:", "internal/", "<anonymous>"},
+		LanguagePython: {"site-packages/", "dist-packages/", "/lib/python", "<frozen", "<stdin>"},
+		LanguageRuby:   {"/gems/", "/lib/ruby/"},
+		LanguagePHP:    {"/vendor/"},
+	}
+)
+
+type CauseLink int
+
+const (
+	CauseLinkNone CauseLink = iota
+	// Java `Caused by:` and .NET inner exceptions
+	CauseLinkCausedBy
+	// Python `The above exception was the direct cause of the following exception:`
+	CauseLinkDirectCause
+	// Python `During handling of the above exception, another exception occurred:`
+	CauseLinkDuringHandling
+)
+
+// StackTrace is a parsed stack trace.
+// Exceptions start with the outermost (reported) exception, each next one is the cause of the previous.
+type StackTrace struct {
+	Language   Language
+	Exceptions []*ChainedException
+}
+
+type ChainedException struct {
+	Type    string
+	Message string
+	// Link describes how this exception relates to the previous one in the chain.
+	Link CauseLink
+	// Frames start with the innermost (most recent) call regardless of the order the language prints them in.
+	Frames []Frame
+	// ElidedFrames is the number of frames omitted by Java as `... N more`.
+	ElidedFrames int
+}
+
+type Frame struct {
+	Module   string
+	Function string
+	File     string
+	Line     int
+	InApp    bool
+}
+
+func (f Frame) qualifiedFunction() string {
+	if f.Module == "" {
+		return f.Function
+	}
+	if f.Function == "" {
+		return f.Module
+	}
+	return f.Module + "." + f.Function
+}
+
+// ParseStackTrace parses a multiline message assembled by MultilineCollector into a StackTrace.
+func ParseStackTrace(content string) *StackTrace {
+	e := DetectException(content)
+	if e == nil {
+		return nil
+	}
+	lines := strings.Split(content, "\n")
+	st := &StackTrace{Language: e.Language}
+	switch e.Language {
+	case LanguageJava:
+		st.Exceptions = parseJavaExceptions(lines, e)
+	case LanguagePython:
+		st.Exceptions = parsePythonExceptions(lines)
+	case LanguageDotNet:
+		st.Exceptions = parseDotNetExceptions(lines, e)
+	case LanguageGo:
+		for i, l := range lines {
+			if i > 0 && strings.HasPrefix(l, "goroutine ") && strings.HasSuffix(l, "]:") && hasGoFrames(lines[:i]) {
+				lines = lines[:i] // only the panicking goroutine
+				break
+			}
+		}
+		fallthrough
+	default:
+		st.Exceptions = []*ChainedException{{Type: e.Type, Message: e.Message, Frames: parseStackFrames(e.Language, lines)}}
+	}
+	return st
+}
+
+func (m Message) StackTrace() *StackTrace {
+	return ParseStackTrace(m.Content)
+}
+
+func hasGoFrames(lines []string) bool {
+	for _, l := range lines {
+		if goFileRe.MatchString(l) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseJavaExceptions(lines []string, e *Exception) []*ChainedException {
+	current := &ChainedException{Type: e.Type, Message: e.Message}
+	res := []*ChainedException{current}
+	var chunk []string
+	flush := func() {
+		current.Frames = append(current.Frames, parseStackFrames(LanguageJava, chunk)...)
+		chunk = chunk[:0]
+	}
+	for _, l := range lines {
+		if cause, ok := strings.CutPrefix(l, "Caused by: "); ok {
+			flush()
+			typ, msg, _ := strings.Cut(cause, ": ")
+			current = &ChainedException{Type: typ, Message: msg, Link: CauseLinkCausedBy}
+			res = append(res, current)
+			continue
+		}
+		if m := javaElidedRe.FindStringSubmatch(l); m != nil {
+			current.ElidedFrames = atoi(m[1])
+			continue
+		}
+		chunk = append(chunk, l)
+	}
+	flush()
+	return res
+}
+
+func parsePythonExceptions(lines []string) []*ChainedException {
+	var res []*ChainedException
+	link := CauseLinkNone
+	start := 0
+	add := func(end int) {
+		segment := lines[start:end]
+		typ, msg := pythonException(segment)
+		res = append([]*ChainedException{{Type: typ, Message: msg, Frames: parseStackFrames(LanguagePython, segment)}}, res...)
+		if len(res) > 1 {
+			res[1].Link = link
+		}
+	}
+	for i, l := range lines {
+		var next CauseLink
+		switch l {
+		case "The above exception was the direct cause of the following exception:":
+			next = CauseLinkDirectCause
+		case "During handling of the above exception, another exception occurred:":
+			next = CauseLinkDuringHandling
+		default:
+			continue
+		}
+		add(i)
+		link = next
+		start = i + 1
+	}
+	add(len(lines))
+	return res
+}
+
+// inner exceptions are printed before the frames of the outer one:
+//
+//	System.InvalidOperationException: Outer
+//	 ---> System.ArgumentException: Inner
+//	   at Inner.Frame()
+//	   --- End of inner exception stack trace ---
+//	   at Outer.Frame()
+func parseDotNetExceptions(lines []string, e *Exception) []*ChainedException {
+	res := []*ChainedException{{Type: e.Type, Message: e.Message}}
+	depth := 0
+	for _, l := range lines {
+		t := strings.TrimLeft(l, " \t")
+		switch {
+		case strings.HasPrefix(t, "---> "):
+			inner := &ChainedException{Link: CauseLinkCausedBy}
+			if m := exceptionRe.FindStringSubmatch(strings.TrimPrefix(t, "---> ")); m != nil {
+				inner.Type, inner.Message = m[1], m[2]
+			}
+			res = append(res, inner)
+			depth = len(res) - 1
+		case strings.HasPrefix(t, "--- End of inner exception stack trace ---"):
+			if depth > 0 {
+				depth--
+			}
+		default:
+			res[depth].Frames = append(res[depth].Frames, parseStackFrames(LanguageDotNet, []string{l})...)
+		}
+	}
+	return res
+}
+
+func parseStackFrames(language Language, lines []string) []Frame {
+	var frames []Frame
+	for i, l := range lines {
+		var f Frame
+		var m []string
+		switch language {
+		case LanguageJava, LanguageDotNet:
+			re := javaFrameRe
+			if language == LanguageDotNet {
+				re = dotnetFrameRe
+			}
+			if m = re.FindStringSubmatch(l); m != nil {
+				f = Frame{Function: m[1], File: m[2], Line: atoi(m[3])}
+				if i := strings.LastIndexByte(f.Function, '/'); i >= 0 { // java.base/java.lang.Thread.run
+					f.Function = f.Function[i+1:]
+				}
+				if i := strings.LastIndexByte(f.Function, '.'); i > 0 {
+					f.Module, f.Function = f.Function[:i], f.Function[i+1:]
+				}
+			}
+		case LanguageNodeJS:
+			if m = nodeFrameRe.FindStringSubmatch(l); m != nil {
+				f = Frame{Module: nodeModule(m[2]), Function: m[1], File: m[2], Line: atoi(m[3])}
+			}
+		case LanguagePython:
+			if m = pythonFrameRe.FindStringSubmatch(l); m != nil {
+				f = Frame{Function: m[3], File: m[1], Line: atoi(m[2])}
+			}
+		case LanguageGo:
+			if l == "" || l[0] == ' ' || l[0] == '\t' || strings.HasPrefix(l, "created by ") || i+1 >= len(lines) {
+				continue
+			}
+			if m = goFileRe.FindStringSubmatch(lines[i+1]); m != nil {
+				f = Frame{Function: stripGoArgs(l), File: m[1], Line: atoi(m[2])}
+				f.Module, f.Function = splitGoFunction(f.Function)
+			}
+		case LanguageRuby:
+			if m = rubyFrameRe.FindStringSubmatch(l); m != nil {
+				f = Frame{Function: m[3], File: m[1], Line: atoi(m[2])}
+			}
+		case LanguagePHP:
+			if m = phpFrameRe.FindStringSubmatch(l); m != nil && m[3] != "{main}" {
+				f = Frame{Function: m[3], File: m[1], Line: atoi(m[2])}
+			}
+		}
+		if m != nil && (f.Function != "" || f.File != "") {
+			f.InApp = isInAppFrame(language, f, nil)
+			frames = append(frames, f)
+		}
+	}
+	if language == LanguagePython { // most recent call last
+		slices.Reverse(frames)
+	}
+	return frames
+}
+
+// net/http.(*conn).serve(0x14001dbe090, {0x1034cd180, 0x14000c412c0}) -> net/http.(*conn).serve
+func stripGoArgs(l string) string {
+	if !strings.HasSuffix(l, ")") {
+		return l
+	}
+	depth := 0
+	for i := len(l) - 1; i >= 0; i-- {
+		switch l[i] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				return l[:i]
+			}
+		}
+	}
+	return l
+}
+
+// github.com/coroot/coroot/api.(*Api).App -> github.com/coroot/coroot/api, (*Api).App
+func splitGoFunction(f string) (string, string) {
+	slash := strings.LastIndexByte(f, '/')
+	if i := strings.IndexByte(f[slash+1:], '.'); i >= 0 {
+		i += slash + 1
+		return f[:i], f[i+1:]
+	}
+	return "", f
+}
+
+// /app/node_modules/@grpc/grpc-js/build/src/call.js -> @grpc/grpc-js
+func nodeModule(file string) string {
+	i := strings.LastIndex(file, "node_modules/")
+	if i < 0 {
+		return ""
+	}
+	parts := strings.SplitN(file[i+len("node_modules/"):], "/", 3)
+	if strings.HasPrefix(parts[0], "@") && len(parts) > 1 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
+
+func isInAppFrame(language Language, f Frame, inAppPrefixes []string) bool {
+	function := f.qualifiedFunction()
+	if len(inAppPrefixes) > 0 {
+		for _, p := range inAppPrefixes {
+			if strings.HasPrefix(function, p) || strings.Contains(f.File, p) {
+				return true
+			}
+		}
+		return false
+	}
+	if language == LanguageGo {
+		// dependencies are built from the module cache or the vendor directory
+		if strings.Contains(f.File, "/pkg/mod/") || strings.Contains(f.File, "/vendor/") {
+			return false
+		}
+		// standard library packages have no dots in the first path element
+		pkg, _, _ := strings.Cut(f.Module, "/")
+		return f.Module == "main" || strings.Contains(pkg, ".") && pkg != "golang.org"
+	}
+	for _, p := range frameworkFrames[language] {
+		if strings.HasPrefix(function, p) || strings.Contains(f.File, p) {
+			return false
+		}
+	}
+	return true
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,173 @@
+package logparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStackTraceJava(t *testing.T) {
+	st := ParseStackTrace(`Exception in thread "main" java.lang.IllegalStateException: Failed to process
+	at com.example.MyClass.methodA(MyClass.java:10)
+	at java.base/java.lang.Thread.run(Thread.java:833)
+Caused by: java.lang.ArrayIndexOutOfBoundsException: Index 5 out of bounds for length 5
+	at com.example.AnotherClass.anotherMethod(AnotherClass.java:15)
+	at sun.reflect.GeneratedMethodAccessor5.invoke(Unknown Source)
+	... 2 more`)
+	require.NotNil(t, st)
+	assert.Equal(t, LanguageJava, st.Language)
+	require.Len(t, st.Exceptions, 2)
+
+	e := st.Exceptions[0]
+	assert.Equal(t, "java.lang.IllegalStateException", e.Type)
+	assert.Equal(t, "Failed to process", e.Message)
+	assert.Equal(t, CauseLinkNone, e.Link)
+	assert.Equal(t, []Frame{
+		{Module: "com.example.MyClass", Function: "methodA", File: "MyClass.java", Line: 10, InApp: true},
+		{Module: "java.lang.Thread", Function: "run", File: "Thread.java", Line: 833, InApp: false},
+	}, e.Frames)
+
+	e = st.Exceptions[1]
+	assert.Equal(t, "java.lang.ArrayIndexOutOfBoundsException", e.Type)
+	assert.Equal(t, "Index 5 out of bounds for length 5", e.Message)
+	assert.Equal(t, CauseLinkCausedBy, e.Link)
+	assert.Equal(t, 2, e.ElidedFrames)
+	assert.Equal(t, []Frame{
+		{Module: "com.example.AnotherClass", Function: "anotherMethod", File: "AnotherClass.java", Line: 15, InApp: true},
+		{Module: "sun.reflect.GeneratedMethodAccessor5", Function: "invoke", File: "Unknown Source", InApp: false},
+	}, e.Frames)
+}
+
+func TestParseStackTracePython(t *testing.T) {
+	st := ParseStackTrace(`Traceback (most recent call last):
+  File "/app/main.py", line 10, in connect
+    raise ConnectionError
+ConnectionError
+
+The above exception was the direct cause of the following exception:
+
+Traceback (most recent call last):
+  File "/usr/local/lib/python3.8/site-packages/db/pool.py", line 12, in get
+    raise RuntimeError('Failed to open database') from exc
+RuntimeError: Failed to open database`)
+	require.NotNil(t, st)
+	assert.Equal(t, LanguagePython, st.Language)
+	require.Len(t, st.Exceptions, 2)
+
+	assert.Equal(t, &ChainedException{
+		Type:    "RuntimeError",
+		Message: "Failed to open database",
+		Frames:  []Frame{{Function: "get", File: "/usr/local/lib/python3.8/site-packages/db/pool.py", Line: 12}},
+	}, st.Exceptions[0])
+	assert.Equal(t, &ChainedException{
+		Type:   "ConnectionError",
+		Link:   CauseLinkDirectCause,
+		Frames: []Frame{{Function: "connect", File: "/app/main.py", Line: 10, InApp: true}},
+	}, st.Exceptions[1])
+
+	st = ParseStackTrace(`Traceback (most recent call last):
+  File "/app/db.py", line 5, in connect
+    raise ConnectionError('refused')
+ConnectionError: refused
+
+The above exception was the direct cause of the following exception:
+
+Traceback (most recent call last):
+  File "/app/pool.py", line 12, in get
+    raise RuntimeError('no connection') from exc
+RuntimeError: no connection
+
+During handling of the above exception, another exception occurred:
+
+Traceback (most recent call last):
+  File "/app/main.py", line 20, in handle
+    log.error(undefined)
+NameError: name 'undefined' is not defined`)
+	require.NotNil(t, st)
+	require.Len(t, st.Exceptions, 3)
+	assert.Equal(t, "NameError", st.Exceptions[0].Type)
+	assert.Equal(t, CauseLinkNone, st.Exceptions[0].Link)
+	assert.Equal(t, "RuntimeError", st.Exceptions[1].Type)
+	assert.Equal(t, CauseLinkDuringHandling, st.Exceptions[1].Link)
+	assert.Equal(t, "ConnectionError", st.Exceptions[2].Type)
+	assert.Equal(t, CauseLinkDirectCause, st.Exceptions[2].Link)
+
+	// frames are printed with the most recent call last
+	st = ParseStackTrace(`Traceback (most recent call last):
+  File "/app/main.py", line 20, in <module>
+    main()
+  File "/app/main.py", line 12, in main
+    handle(request)
+  File "/app/handlers/users.py", line 7, in handle
+    return users[request.user_id]
+KeyError: 42`)
+	require.NotNil(t, st)
+	require.Len(t, st.Exceptions, 1)
+	assert.Equal(t, []Frame{
+		{Function: "handle", File: "/app/handlers/users.py", Line: 7, InApp: true},
+		{Function: "main", File: "/app/main.py", Line: 12, InApp: true},
+		{Function: "<module>", File: "/app/main.py", Line: 20, InApp: true},
+	}, st.Exceptions[0].Frames)
+}
+
+func TestParseStackTraceGo(t *testing.T) {
+	st := ParseStackTrace(`panic: runtime error: invalid memory address or nil pointer dereference
+[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x47e8c2]
+
+goroutine 1 [running]:
+github.com/coroot/coroot/api.(*Api).App(0x1034cd180?, {0x1034cab30, 0x1400239a0e0})
+	/app/api/api.go:377 +0x228
+github.com/gorilla/mux.(*Router).ServeHTTP(0x140000fa180)
+	/go/pkg/mod/github.com/gorilla/mux@v1.8.0/mux.go:210 +0x194
+github.com/example/lib.Run()
+	/app/vendor/github.com/example/lib/run.go:5 +0x10
+main.main()
+	/app/main.go:9 +0x22
+
+goroutine 7 [chan receive]:
+net/http.(*conn).serve(0x14001dbe090)
+	/usr/local/go/src/net/http/server.go:2009 +0x518`)
+	require.NotNil(t, st)
+	require.Len(t, st.Exceptions, 1)
+	assert.Equal(t, "runtime error", st.Exceptions[0].Type)
+	assert.Equal(t, []Frame{
+		{Module: "github.com/coroot/coroot/api", Function: "(*Api).App", File: "/app/api/api.go", Line: 377, InApp: true},
+		{Module: "github.com/gorilla/mux", Function: "(*Router).ServeHTTP", File: "/go/pkg/mod/github.com/gorilla/mux@v1.8.0/mux.go", Line: 210, InApp: false},
+		{Module: "github.com/example/lib", Function: "Run", File: "/app/vendor/github.com/example/lib/run.go", Line: 5, InApp: false},
+		{Module: "main", Function: "main", File: "/app/main.go", Line: 9, InApp: true},
+	}, st.Exceptions[0].Frames)
+}
+
+func TestParseStackTraceNodeJS(t *testing.T) {
+	st := ParseStackTrace(`Error: 14 UNAVAILABLE: read ECONNRESET
+    at callErrorFromStatus (/app/node_modules/@grpc/grpc-js/build/src/call.js:31:19)
+    at /app/src/client.js:99:78
+    at process.processTicksAndRejections (node:internal/process/task_queues:77:11)`)
+	require.NotNil(t, st)
+	require.Len(t, st.Exceptions, 1)
+	assert.Equal(t, "Error", st.Exceptions[0].Type)
+	assert.Equal(t, "14 UNAVAILABLE: read ECONNRESET", st.Exceptions[0].Message)
+	assert.Equal(t, []Frame{
+		{Module: "@grpc/grpc-js", Function: "callErrorFromStatus", File: "/app/node_modules/@grpc/grpc-js/build/src/call.js", Line: 31},
+		{File: "/app/src/client.js", Line: 99, InApp: true},
+		{Function: "process.processTicksAndRejections", File: "node:internal/process/task_queues", Line: 77},
+	}, st.Exceptions[0].Frames)
+}
+
+func TestParseStackTraceDotNet(t *testing.T) {
+	st := ParseStackTrace(`System.InvalidOperationException: Failed to load the order
+ ---> System.Data.SqlClient.SqlException: Timeout expired.
+   at Orders.Repository.Load(Int32 id) in /src/Orders/Repository.cs:line 42
+   --- End of inner exception stack trace ---
+   at Orders.Service.Get(Int32 id) in /src/Orders/Service.cs:line 17`)
+	require.NotNil(t, st)
+	require.Len(t, st.Exceptions, 2)
+	assert.Equal(t, "System.InvalidOperationException", st.Exceptions[0].Type)
+	assert.Equal(t, []Frame{{Module: "Orders.Service", Function: "Get", File: "/src/Orders/Service.cs", Line: 17, InApp: true}}, st.Exceptions[0].Frames)
+	assert.Equal(t, "System.Data.SqlClient.SqlException", st.Exceptions[1].Type)
+	assert.Equal(t, CauseLinkCausedBy, st.Exceptions[1].Link)
+	assert.Equal(t, []Frame{{Module: "Orders.Repository", Function: "Load", File: "/src/Orders/Repository.cs", Line: 42, InApp: true}}, st.Exceptions[1].Frames)
+
+	assert.Nil(t, ParseStackTrace("a plain\nmultiline message"))
+}
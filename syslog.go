@@ -0,0 +1,197 @@
+package logparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyslogDecoder parses syslog messages in the RFC 3164 (BSD) and RFC 5424 formats:
+//
+//	<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8
+//	<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3"] An application event
+//
+// The level is derived from the severity of the priority, the hostname, app-name, procid, msgid
+// and structured data parameters are returned as labels.
+type SyslogDecoder struct {
+	// Clock and Location are used to resolve RFC 3164 timestamps which have neither the year nor the zone.
+	Clock    Clock
+	Location *time.Location
+}
+
+const (
+	SyslogLabelHost   = "host"
+	SyslogLabelApp    = "app"
+	SyslogLabelProcID = "procid"
+	SyslogLabelMsgID  = "msgid"
+)
+
+func (d SyslogDecoder) Decode(src string) (string, error) {
+	entry, err := d.DecodeEntry(src)
+	return entry.Content, err
+}
+
+func (d SyslogDecoder) DecodeEntry(src string) (LogEntry, error) {
+	pri, rest, ok := parseSyslogPriority(src)
+	if !ok {
+		return LogEntry{}, fmt.Errorf("invalid syslog priority: %s", src)
+	}
+	entry := LogEntry{Level: LevelByPriority(strconv.Itoa(pri % 8)), Labels: map[string]string{}}
+	var err error
+	if len(rest) > 2 && rest[0] >= '1' && rest[0] <= '9' && rest[1] == ' ' {
+		err = d.parse5424(rest[2:], &entry)
+	} else {
+		err = d.parse3164(rest, &entry)
+	}
+	if err != nil {
+		return LogEntry{}, fmt.Errorf("%s: %s", err, src)
+	}
+	return entry, nil
+}
+
+// <PRI> is 1-3 digits, 0-191
+func parseSyslogPriority(s string) (int, string, bool) {
+	if len(s) < 3 || s[0] != '<' {
+		return 0, "", false
+	}
+	end := strings.IndexByte(s, '>')
+	if end < 2 || end > 4 {
+		return 0, "", false
+	}
+	pri, err := strconv.Atoi(s[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, "", false
+	}
+	return pri, s[end+1:], true
+}
+
+// 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3"] BOMAn application event
+func (d SyslogDecoder) parse5424(s string, entry *LogEntry) error {
+	var fields [5]string
+	for i := range fields {
+		end := strings.IndexByte(s, ' ')
+		if end < 0 {
+			return fmt.Errorf("invalid syslog header")
+		}
+		fields[i], s = s[:end], s[end+1:]
+	}
+	if fields[0] != "-" {
+		ts, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid syslog timestamp")
+		}
+		entry.Timestamp = ts
+	}
+	for i, label := range []string{SyslogLabelHost, SyslogLabelApp, SyslogLabelProcID, SyslogLabelMsgID} {
+		if v := fields[i+1]; v != "-" {
+			entry.Labels[label] = v
+		}
+	}
+	msg, ok := parseStructuredData(s, entry.Labels)
+	if !ok {
+		return fmt.Errorf("invalid syslog structured data")
+	}
+	entry.Content = strings.TrimPrefix(msg, "\xef\xbb\xbf")
+	return nil
+}
+
+// parseStructuredData parses `-` or `[id param="value" ...]...` adding the params as `id.param` labels and returns the message
+func parseStructuredData(s string, labels map[string]string) (string, bool) {
+	if strings.HasPrefix(s, "-") {
+		return strings.TrimPrefix(s[1:], " "), true
+	}
+	for strings.HasPrefix(s, "[") {
+		s = s[1:]
+		end := strings.IndexAny(s, " ]")
+		if end < 0 {
+			return "", false
+		}
+		id := s[:end]
+		s = s[end:]
+		for strings.HasPrefix(s, " ") {
+			s = s[1:]
+			eq := strings.Index(s, `="`)
+			if eq < 0 {
+				return "", false
+			}
+			name := s[:eq]
+			s = s[eq+2:]
+			var value strings.Builder
+			i := 0
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\' || s[i+1] == ']') {
+					i++
+				}
+				value.WriteByte(s[i])
+			}
+			if i == len(s) {
+				return "", false
+			}
+			labels[id+"."+name] = value.String()
+			s = s[i+1:]
+		}
+		if !strings.HasPrefix(s, "]") {
+			return "", false
+		}
+		s = s[1:]
+	}
+	return strings.TrimPrefix(s, " "), true
+}
+
+// Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8
+// the hostname is omitted in the messages sent to the local socket: `Oct 11 22:14:15 su[123]: ...`
+func (d SyslogDecoder) parse3164(s string, entry *LogEntry) error {
+	if len(s) >= len(time.Stamp) {
+		if ts, err := time.Parse(time.Stamp, s[:len(time.Stamp)]); err == nil {
+			ref := time.Now()
+			if d.Clock != nil {
+				ref = d.Clock.Now()
+			}
+			r := TimestampResolver{Location: d.Location}
+			entry.Timestamp = r.Resolve(Timestamp{Time: ts}, ref)
+			s = strings.TrimPrefix(s[len(time.Stamp):], " ")
+		}
+	}
+	if entry.Timestamp.IsZero() { // some daemons (e.g., rsyslog) send RFC 3339 timestamps
+		if end := strings.IndexByte(s, ' '); end > 0 {
+			if ts, err := time.Parse(time.RFC3339Nano, s[:end]); err == nil {
+				entry.Timestamp = ts
+				s = s[end+1:]
+			}
+		}
+	}
+	if entry.Timestamp.IsZero() {
+		return fmt.Errorf("invalid syslog timestamp")
+	}
+	fields := strings.SplitN(s, " ", 3)
+	switch {
+	case len(fields) > 1 && isSyslogTag(fields[0]):
+		d.setTag(fields[0], entry)
+		s = s[len(fields[0])+1:]
+	case len(fields) > 2 && isSyslogTag(fields[1]):
+		entry.Labels[SyslogLabelHost] = fields[0]
+		d.setTag(fields[1], entry)
+		s = fields[2]
+	}
+	entry.Content = s
+	return nil
+}
+
+func (d SyslogDecoder) setTag(tag string, entry *LogEntry) {
+	tag = strings.TrimSuffix(tag, ":")
+	if i := strings.IndexByte(tag, '['); i > 0 && strings.HasSuffix(tag, "]") {
+		entry.Labels[SyslogLabelProcID] = tag[i+1 : len(tag)-1]
+		tag = tag[:i]
+	}
+	entry.Labels[SyslogLabelApp] = tag
+}
+
+// su:, su[123]:, sshd[123]
+func isSyslogTag(s string) bool {
+	if strings.HasSuffix(s, ":") {
+		return len(s) > 1
+	}
+	i := strings.IndexByte(s, '[')
+	return i > 0 && strings.HasSuffix(s, "]")
+}
@@ -0,0 +1,85 @@
+package logparser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogDecoder(t *testing.T) {
+	d := SyslogDecoder{Clock: fakeClock{now: time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)}}
+
+	check := func(src string, ts time.Time, level Level, content string, labels map[string]string) {
+		t.Helper()
+		entry, err := d.DecodeEntry(src)
+		require.NoError(t, err)
+		assert.True(t, ts.Equal(entry.Timestamp), "expected %s, got %s", ts, entry.Timestamp)
+		assert.Equal(t, level, entry.Level)
+		assert.Equal(t, content, entry.Content)
+		assert.Equal(t, labels, entry.Labels)
+	}
+
+	// RFC 3164
+	check(`<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8`,
+		time.Date(2025, 10, 11, 22, 14, 15, 0, time.UTC), LevelCritical, `'su root' failed for lonvick on /dev/pts/8`,
+		map[string]string{"host": "mymachine", "app": "su"})
+	check(`<38>Jan  2 09:59:58 web-1 sshd[2718]: Accepted publickey for root from 10.0.0.5 port 51234 ssh2: RSA SHA256:abc`,
+		time.Date(2026, 1, 2, 9, 59, 58, 0, time.UTC), LevelInfo, `Accepted publickey for root from 10.0.0.5 port 51234 ssh2: RSA SHA256:abc`,
+		map[string]string{"host": "web-1", "app": "sshd", "procid": "2718"})
+	check(`<78>Dec 31 23:59:01 CRON[12345]: (root) CMD (command -v debian-sa1 > /dev/null && debian-sa1 1 1)`,
+		time.Date(2025, 12, 31, 23, 59, 1, 0, time.UTC), LevelInfo, `(root) CMD (command -v debian-sa1 > /dev/null && debian-sa1 1 1)`,
+		map[string]string{"app": "CRON", "procid": "12345"})
+	check(`<3>Jan  2 09:00:00 node-1 kernel: [12345.678901] EXT4-fs error (device sda1): ext4_find_entry:1455: inode #2: comm ls: reading directory lblock 0`,
+		time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC), LevelError, `[12345.678901] EXT4-fs error (device sda1): ext4_find_entry:1455: inode #2: comm ls: reading directory lblock 0`,
+		map[string]string{"host": "node-1", "app": "kernel"})
+	check(`<20>Jan  2 09:30:00 mail postfix/smtp[4242]: 3F2A1: to=<user@example.com>, relay=none, delay=30, status=deferred (connect to example.com[93.184.216.34]:25: Connection timed out)`,
+		time.Date(2026, 1, 2, 9, 30, 0, 0, time.UTC), LevelWarning, `3F2A1: to=<user@example.com>, relay=none, delay=30, status=deferred (connect to example.com[93.184.216.34]:25: Connection timed out)`,
+		map[string]string{"host": "mail", "app": "postfix/smtp", "procid": "4242"})
+	check(`<190>Jan  2 09:45:01 lb haproxy[1001]: 10.0.1.2:54321 [02/Jan/2026:09:45:01.123] fe be/srv1 0/0/1/2/3 200 512 - - ---- 1/1/0/0/0 0/0 "GET / HTTP/1.1"`,
+		time.Date(2026, 1, 2, 9, 45, 1, 0, time.UTC), LevelInfo, `10.0.1.2:54321 [02/Jan/2026:09:45:01.123] fe be/srv1 0/0/1/2/3 200 512 - - ---- 1/1/0/0/0 0/0 "GET / HTTP/1.1"`,
+		map[string]string{"host": "lb", "app": "haproxy", "procid": "1001"})
+	check(`<29>2026-01-02T09:15:00.123456+01:00 host systemd[1]: Started Daily apt upgrade and clean activities.`,
+		time.Date(2026, 1, 2, 8, 15, 0, 123456000, time.UTC), LevelNotice, `Started Daily apt upgrade and clean activities.`,
+		map[string]string{"host": "host", "app": "systemd", "procid": "1"})
+	check(`<13>Jan  2 09:50:00 just a message`,
+		time.Date(2026, 1, 2, 9, 50, 0, 0, time.UTC), LevelNotice, `just a message`,
+		map[string]string{})
+
+	// RFC 5424
+	check(`<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - `+"\xef\xbb\xbf"+`'su root' failed for lonvick on /dev/pts/8`,
+		time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC), LevelCritical, `'su root' failed for lonvick on /dev/pts/8`,
+		map[string]string{"host": "mymachine.example.com", "app": "su", "msgid": "ID47"})
+	check(`<165>1 2003-08-24T05:14:15.000003-07:00 192.0.2.1 myproc 8710 - - %% It's time to make the do-nuts.`,
+		time.Date(2003, 8, 24, 12, 14, 15, 3000, time.UTC), LevelNotice, `%% It's time to make the do-nuts.`,
+		map[string]string{"host": "192.0.2.1", "app": "myproc", "procid": "8710"})
+	check(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"][examplePriority@32473 class="high \"x\" \]"] An application event log entry...`,
+		time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC), LevelNotice, `An application event log entry...`,
+		map[string]string{"host": "mymachine.example.com", "app": "evntslog", "msgid": "ID47",
+			"exampleSDID@32473.iut": "3", "exampleSDID@32473.eventSource": "Application", "exampleSDID@32473.eventID": "1011",
+			"examplePriority@32473.class": `high "x" ]`})
+	check(`<11>1 2026-01-02T09:00:00Z db-0 postgres 77 - [meta sequenceId="29"] FATAL:  password authentication failed for user "app"`,
+		time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC), LevelError, `FATAL:  password authentication failed for user "app"`,
+		map[string]string{"host": "db-0", "app": "postgres", "procid": "77", "meta.sequenceId": "29"})
+	check(`<14>1 - - - - - -`, time.Time{}, LevelInfo, ``, map[string]string{})
+
+	for _, src := range []string{
+		`Oct 11 22:14:15 mymachine su: no priority`,
+		`<192>Oct 11 22:14:15 mymachine su: invalid priority`,
+		`<34>not a timestamp`,
+		`<34>1 2003-10-11T22:14:15.003Z host app`,
+		`<34>1 2003-10-11T22:14:15.003Z host app - ID47 [broken`,
+	} {
+		_, err := d.DecodeEntry(src)
+		assert.Error(t, err, src)
+	}
+}
+
+func TestSyslogDecoderLocation(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*3600)
+	d := SyslogDecoder{Clock: fakeClock{now: time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)}, Location: msk}
+	entry, err := d.DecodeEntry(`<30>Jan  2 12:59:58 host app: hello`)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2026, 1, 2, 9, 59, 58, 0, time.UTC).Equal(entry.Timestamp))
+}
@@ -1,9 +1,34 @@
 package logparser
 
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
 const (
 	lookForTimestampLimit = 100
+	// leadingTimestampLimit is how far from the line start a timestamp marking the beginning of a message may be
+	leadingTimestampLimit = 40
 )
 
+var (
+	// 2024-02-16 |, 16.02.2024, 1700000000.123, [9:05], [   12.345678] (seconds since boot)
+	leadingDateRe = regexp.MustCompile(`^(?:<\d{1,3}>)?[\[(]?(?:\s*\d+\.\d{1,9}\]|\d{4}[-/.]\d{2}[-/.]\d{2}|\d{2}[-/.]\d{2}[-/.]\d{4}|\d{10}(?:\d{3})?(?:\.\d+)?|\d{1,2}:\d{2})(?:[^\d:]|$)`)
+	// [9:05:01], 9:05:01.123
+	clockTimeRe = regexp.MustCompile(`(?:^|[^\d.])\d{1,2}:\d{2}:\d{2}(?:[^\d:]|$)`)
+)
+
+// hasLeadingTimestamp reports whether the line starts with a timestamp (or has one close to the start, like
+// `10.42.0.21 - - [30/Oct/2023:11:55:47 +0000]`), i.e. the line is likely the first line of a message.
+func hasLeadingTimestamp(line string) bool {
+	prefix := line
+	if len(prefix) > leadingTimestampLimit {
+		prefix = prefix[:leadingTimestampLimit]
+	}
+	return containsTimestamp(prefix) || leadingDateRe.MatchString(line) || clockTimeRe.MatchString(prefix)
+}
+
 func containsTimestamp(line string) bool {
 	if len(line) > lookForTimestampLimit {
 		line = line[:lookForTimestampLimit]
@@ -31,3 +56,205 @@ func containsTimestamp(line string) bool {
 	}
 	return false
 }
+
+const (
+	months = `(Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)`
+	days   = `(?:Mon|Tue|Wed|Thu|Fri|Sat|Sun)`
+	hms    = `(\d{2}):(\d{2}):(\d{2})(?:[.,](\d{1,9}))?`
+	zone   = `(Z|[+-]\d{2}:?\d{2}|[+-]\d{2}\b| ?UTC\b)?`
+)
+
+// Timestamp is a timestamp found in a log line.
+type Timestamp struct {
+	Time time.Time
+	// Start and End locate the timestamp in the line.
+	Start int
+	End   int
+	// HasYear and HasZone are false for partial timestamps like `Jan 16 21:41:24`,
+	// the missing parts of Time are inferred by TimestampResolver.
+	HasYear bool
+	HasZone bool
+}
+
+type timestampFormat struct {
+	re *regexp.Regexp
+	// indexes of the submatches: year, month, day, hour, minute, second, fraction, zone; -1 if absent
+	groups [8]int
+}
+
+var (
+	timestampFormats = []timestampFormat{
+		// 2005-08-09T18:31:42.201Z, 2003-07-08 16:49:45,896 (python asctime), 2024/02/29 11:01:03 (nginx), 2023.10.12 13:58:41.168802
+		{regexp.MustCompile(`(\d{4})[-/.](\d{2})[-/.](\d{2})[T ]` + hms + zone), [8]int{1, 2, 3, 4, 5, 6, 7, 8}},
+		// 10/Oct/2000:13:55:36 -0700 (nginx & apache access logs)
+		{regexp.MustCompile(`(\d{2})/` + months + `/(\d{4}):` + hms + `(?: ([+-]\d{4}))?`), [8]int{3, 2, 1, 4, 5, 6, 7, 8}},
+		// Sat Dec 04 04:51:18 2020 (apache error log, ANSIC)
+		{regexp.MustCompile(days + ` ` + months + ` +(\d{1,2}) ` + hms + ` (\d{4})`), [8]int{7, 1, 2, 3, 4, 5, 6, -1}},
+		// 12 Nov 07:52:11.999, 12 Nov 2019 07:52:11.999 (redis)
+		{regexp.MustCompile(`\b(\d{1,2}) ` + months + ` (?:(\d{4}) )?` + hms), [8]int{3, 2, 1, 4, 5, 6, 7, -1}},
+		// Jan 16 21:41:24 (syslog)
+		{regexp.MustCompile(`\b` + months + ` +(\d{1,2}) ` + hms), [8]int{-1, 1, 2, 3, 4, 5, 6, -1}},
+		// I0430 11:58:31.792717 (glog)
+		{regexp.MustCompile(`^[IWEF](\d{2})(\d{2}) ` + hms), [8]int{-1, 1, 2, 3, 4, 5, 6, -1}},
+	}
+	// {"ts":1700000000.123}, {"time":1531171074631}
+	epochTimestampRe = regexp.MustCompile(`"(?:ts|t|time|timestamp|@timestamp|date)"\s*:\s*(\d{10}(?:\.\d{1,9})?|\d{13}|\d{16}|\d{19})\b`)
+)
+
+// TimestampResolver infers the missing year and time zone of partial timestamps
+// from a reference time (usually, the time the entry was received).
+type TimestampResolver struct {
+	// Location is used for timestamps without a zone; the location of the reference time is used if nil.
+	Location *time.Location
+}
+
+var defaultTimestampResolver = &TimestampResolver{}
+
+// ExtractTimestamp finds the first timestamp in the line using the default TimestampResolver.
+func ExtractTimestamp(line string, ref time.Time) (Timestamp, bool) {
+	return defaultTimestampResolver.ExtractTimestamp(line, ref)
+}
+
+// ExtractTimestamp finds the first timestamp in the line and resolves it if it's partial.
+func (r *TimestampResolver) ExtractTimestamp(line string, ref time.Time) (Timestamp, bool) {
+	if len(line) > lookForTimestampLimit {
+		line = line[:lookForTimestampLimit]
+	}
+	var res Timestamp
+	found := false
+	for _, f := range timestampFormats {
+		m := f.re.FindStringSubmatchIndex(line)
+		if m == nil || found && m[0] >= res.Start {
+			continue
+		}
+		if ts, ok := f.parse(line, m, ref); ok {
+			res, found = ts, true
+		}
+	}
+	if found && (!res.HasYear || !res.HasZone) {
+		res.Time = r.Resolve(res, ref)
+	}
+	if m := epochTimestampRe.FindStringSubmatchIndex(line); m != nil && (!found || m[2] < res.Start) {
+		if t, ok := parseEpoch(line[m[2]:m[3]]); ok {
+			res = Timestamp{Time: t, Start: m[2], End: m[3], HasYear: true, HasZone: true}
+			found = true
+		}
+	}
+	return res, found
+}
+
+func (f timestampFormat) parse(line string, m []int, ref time.Time) (Timestamp, bool) {
+	group := func(i int) string {
+		g := f.groups[i]
+		if g < 0 || m[2*g] < 0 {
+			return ""
+		}
+		return line[m[2*g]:m[2*g+1]]
+	}
+	res := Timestamp{Start: m[0], End: m[1], HasYear: group(0) != "", HasZone: group(7) != ""}
+	year := ref.Year()
+	if res.HasYear {
+		year = atoi(group(0))
+	}
+	month := atoi(group(1))
+	if month == 0 {
+		for i, name := range monthNames {
+			if group(1) == name {
+				month = i + 1
+			}
+		}
+	}
+	day, hour, minute, second := atoi(group(2)), atoi(group(3)), atoi(group(4)), atoi(group(5))
+	if month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || minute > 59 || second > 60 {
+		return res, false
+	}
+	var nsec int
+	if fraction := group(6); fraction != "" {
+		nsec = atoi((fraction + "000000000")[:9])
+	}
+	loc := ref.Location()
+	if res.HasZone {
+		loc = parseZone(strings.TrimSpace(group(7)))
+	}
+	res.Time = time.Date(year, time.Month(month), day, hour, minute, second, nsec, loc)
+	return res, true
+}
+
+var monthNames = []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+
+// maxTimestampSkew is how far ahead of the reference time a partial timestamp may be before it's attributed to the previous year.
+const maxTimestampSkew = 24 * time.Hour
+
+// Resolve returns the time of a partial timestamp. The most recent year that doesn't put the timestamp
+// ahead of ref (by more than maxTimestampSkew) is chosen, so a `Dec 31 23:59:59` line received on Jan 1
+// belongs to the previous year. Ambiguous wall clock times (when DST ends) are resolved to the offset closest to ref.
+func (r *TimestampResolver) Resolve(ts Timestamp, ref time.Time) time.Time {
+	t := ts.Time
+	loc := t.Location()
+	if !ts.HasZone {
+		loc = ref.Location()
+		if r.Location != nil {
+			loc = r.Location
+		}
+	}
+	years := []int{t.Year()}
+	if !ts.HasYear {
+		years = []int{ref.Year() + 1, ref.Year(), ref.Year() - 1}
+	}
+	var res time.Time
+	for _, year := range years { // wall times skipped by DST transitions are normalized by time.Date
+		c := time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+		for _, shift := range []time.Duration{-time.Hour, time.Hour} {
+			if alt := c.Add(shift); alt.Hour() == c.Hour() && alt.Minute() == c.Minute() && absDuration(alt.Sub(ref)) < absDuration(c.Sub(ref)) {
+				c = alt
+			}
+		}
+		res = c
+		if ts.HasYear || c.Sub(ref) <= maxTimestampSkew {
+			break
+		}
+	}
+	return res
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func parseZone(z string) *time.Location {
+	if z == "Z" || z == "UTC" {
+		return time.UTC
+	}
+	sign := 1
+	if z[0] == '-' {
+		sign = -1
+	}
+	z = strings.ReplaceAll(z[1:], ":", "")
+	offset := atoi(z[:2]) * 3600
+	if len(z) >= 4 {
+		offset += atoi(z[2:4]) * 60
+	}
+	return time.FixedZone("", sign*offset)
+}
+
+func parseEpoch(s string) (time.Time, bool) {
+	sec, frac, _ := strings.Cut(s, ".")
+	switch len(sec) {
+	case 10:
+		var nsec int
+		if frac != "" {
+			nsec = atoi((frac + "000000000")[:9])
+		}
+		return time.Unix(int64(atoi(sec)), int64(nsec)).UTC(), true
+	case 13:
+		return time.UnixMilli(int64(atoi(sec))).UTC(), true
+	case 16:
+		return time.UnixMicro(int64(atoi(sec))).UTC(), true
+	case 19:
+		return time.Unix(0, int64(atoi(sec))).UTC(), true
+	}
+	return time.Time{}, false
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
+	_ "time/tzdata"
 )
 
 func TestContainsTimestamp(t *testing.T) {
@@ -39,9 +40,105 @@ func TestContainsTimestamp(t *testing.T) {
 
 }
 
+func TestHasLeadingTimestamp(t *testing.T) {
+	assert.True(t, hasLeadingTimestamp("2005-08-09T18:31:42.201Z level=info msg=started"))
+	assert.True(t, hasLeadingTimestamp("2024-02-16 | ERROR | payment failed"))
+	assert.True(t, hasLeadingTimestamp("2024/02/16 payment failed"))
+	assert.True(t, hasLeadingTimestamp("16.02.2024 payment failed"))
+	assert.True(t, hasLeadingTimestamp("1700000000.123 ERROR payment failed"))
+	assert.True(t, hasLeadingTimestamp("[1700000000123] payment failed"))
+	assert.True(t, hasLeadingTimestamp("[9:05:01] payment failed"))
+	assert.True(t, hasLeadingTimestamp("9:05:01.123 payment failed"))
+	assert.True(t, hasLeadingTimestamp("12:05 payment failed"))
+	assert.True(t, hasLeadingTimestamp("[12:05] payment failed"))
+	assert.True(t, hasLeadingTimestamp(`10.42.0.21 - - [30/Oct/2023:11:55:47 +0000] "GET / HTTP/1.1" 200 612`))
+	assert.True(t, hasLeadingTimestamp("Jan  6 21:41:24 host sshd[123]: Accepted publickey for root"))
+	assert.True(t, hasLeadingTimestamp("[   12.345678] nvme0: I/O timeout"))
+	assert.True(t, hasLeadingTimestamp("<3>[12345.678901] nvme0: I/O timeout"))
+	assert.True(t, hasLeadingTimestamp("[   12.5] EXT4-fs (sda1): mounted filesystem"))
+
+	assert.False(t, hasLeadingTimestamp("payment failed"))
+	assert.False(t, hasLeadingTimestamp("  at com.example.Payment.process(Payment.java:42)"))
+	assert.False(t, hasLeadingTimestamp("retrying in 12:05"))
+	assert.False(t, hasLeadingTimestamp("1:12:123 foo"))
+	assert.False(t, hasLeadingTimestamp("12345 items processed"))
+	assert.False(t, hasLeadingTimestamp("caused by a request that was sent to the server at 2024-02-16 10:00:00"))
+}
+
 func BenchmarkContainsTimestamp(b *testing.B) {
 	l := `10.42.0.21 - - [30/Oct/2023:11:55:47 +0000] "GET / HTTP/1.1" 200 612 "-" "-" "-"`
 	for n := 0; n < b.N; n++ {
 		containsTimestamp(l)
 	}
 }
+
+func TestExtractTimestamp(t *testing.T) {
+	ref := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	check := func(line string, expected time.Time, span string, hasYear, hasZone bool) {
+		t.Helper()
+		ts, ok := ExtractTimestamp(line, ref)
+		if !assert.True(t, ok) {
+			return
+		}
+		assert.True(t, expected.Equal(ts.Time), "expected %s, got %s", expected, ts.Time)
+		assert.Equal(t, span, line[ts.Start:ts.End])
+		assert.Equal(t, hasYear, ts.HasYear)
+		assert.Equal(t, hasZone, ts.HasZone)
+	}
+	msk := time.FixedZone("", 3*3600)
+
+	check(`2005-08-09T18:31:42.201Z level=info msg=started`, time.Date(2005, 8, 9, 18, 31, 42, 201000000, time.UTC), "2005-08-09T18:31:42.201Z", true, true)
+	check(`2005-08-09T18:31:42+03:00 started`, time.Date(2005, 8, 9, 18, 31, 42, 0, msk), "2005-08-09T18:31:42+03:00", true, true)
+	check(`[2023-10-12T09:56:53.393595+00:00] otel-php.ERROR: Export failure`, time.Date(2023, 10, 12, 9, 56, 53, 393595000, time.UTC), "2023-10-12T09:56:53.393595+00:00", true, true)
+	check(`2003-07-08 16:49:45,896 ERROR [django.request:222] Internal Server Error`, time.Date(2003, 7, 8, 16, 49, 45, 896000000, time.UTC), "2003-07-08 16:49:45,896", true, false)
+	check(`2024/02/29 11:01:03 [emerg] 1#1: duplicate location`, time.Date(2024, 2, 29, 11, 1, 3, 0, time.UTC), "2024/02/29 11:01:03", true, false)
+	check(`10.42.0.21 - - [30/Oct/2023:11:55:47 +0300] "GET / HTTP/1.1" 200 612`, time.Date(2023, 10, 30, 11, 55, 47, 0, msk), "30/Oct/2023:11:55:47 +0300", true, true)
+	check(`[Sat Dec 04 04:51:18 2020] [error] mod_jk child workerEnv in error state 6`, time.Date(2020, 12, 4, 4, 51, 18, 0, time.UTC), "Sat Dec 04 04:51:18 2020", true, false)
+	check(`Jan 16 21:41:24 host sshd[123]: Accepted publickey for root`, time.Date(2026, 1, 16, 21, 41, 24, 0, time.UTC), "Jan 16 21:41:24", false, false)
+	check(`Jan  6 21:41:24 host sshd[123]: Accepted publickey for root`, time.Date(2026, 1, 6, 21, 41, 24, 0, time.UTC), "Jan  6 21:41:24", false, false)
+	check(`I0430 11:58:31.792717       1 cluster.go:337] memberlist`, time.Date(2026, 4, 30, 11, 58, 31, 792717000, time.UTC), "I0430 11:58:31.792717", false, false)
+	check(`1:S 12 Nov 07:52:11.999 * FAIL message received`, time.Date(2025, 11, 12, 7, 52, 11, 999000000, time.UTC), "12 Nov 07:52:11.999", false, false)
+	check(`1:S 12 Nov 2019 07:52:11.999 . verbosed`, time.Date(2019, 11, 12, 7, 52, 11, 999000000, time.UTC), "12 Nov 2019 07:52:11.999", true, false)
+	check(`{"level":30,"time":1531171074631,"msg":"hello world"}`, time.UnixMilli(1531171074631), "1531171074631", true, true)
+	check(`{"ts":1700000000.123,"level":"info"}`, time.Unix(1700000000, 123000000), "1700000000.123", true, true)
+
+	for _, line := range []string{"no timestamp here", "13:32 foo", "2024-13-01 10:00:00 bad month", `{"count":1531171074631}`} {
+		_, ok := ExtractTimestamp(line, ref)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestTimestampResolver(t *testing.T) {
+	extract := func(r *TimestampResolver, line string, ref time.Time) time.Time {
+		t.Helper()
+		ts, ok := r.ExtractTimestamp(line, ref)
+		assert.True(t, ok)
+		return ts.Time
+	}
+	r := &TimestampResolver{}
+
+	// year rollover
+	assert.Equal(t, time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC), extract(r, "Dec 31 23:59:59 host app: bye", time.Date(2027, 1, 1, 0, 0, 5, 0, time.UTC)))
+	assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 1, 0, time.UTC), extract(r, "Jan  1 00:00:01 host app: hi", time.Date(2026, 12, 31, 23, 59, 58, 0, time.UTC)))
+	assert.Equal(t, time.Date(2025, 12, 30, 10, 0, 0, 0, time.UTC), extract(r, "Dec 30 10:00:00 host app: old", time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)))
+
+	// the zone of the reference time
+	msk := time.FixedZone("MSK", 3*3600)
+	assert.True(t, time.Date(2026, 5, 1, 7, 0, 0, 0, time.UTC).Equal(extract(r, "May  1 10:00:00 host app: hi", time.Date(2026, 5, 1, 10, 0, 0, 0, msk))))
+
+	// an explicit zone is kept
+	assert.True(t, time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC).Equal(extract(r, "2026-05-01T10:00:00Z hi", time.Date(2026, 5, 1, 10, 0, 0, 0, msk))))
+
+	ny, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	r = &TimestampResolver{Location: ny}
+	assert.True(t, time.Date(2026, 7, 1, 14, 0, 0, 0, time.UTC).Equal(extract(r, "2026-07-01 10:00:00 hi", time.Date(2026, 7, 1, 14, 0, 0, 0, time.UTC))))
+
+	// DST ends: 01:30 happens twice
+	line := "Nov  1 01:30:00 host app: hi"
+	assert.True(t, time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC).Equal(extract(r, line, time.Date(2026, 11, 1, 6, 35, 0, 0, time.UTC))))
+	assert.True(t, time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC).Equal(extract(r, line, time.Date(2026, 11, 1, 5, 35, 0, 0, time.UTC))))
+
+	// DST starts: 02:30 doesn't exist, it's interpreted as 02:30 EDT
+	assert.True(t, time.Date(2026, 3, 8, 6, 30, 0, 0, time.UTC).Equal(extract(r, "Mar  8 02:30:00 host app: hi", time.Date(2026, 3, 8, 7, 31, 0, 0, time.UTC))))
+}